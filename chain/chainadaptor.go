@@ -13,4 +13,12 @@ type IChainAdaptor interface {
 	SignTransactionMessage(ctx context.Context, req *wallet.GetSignTransactionMessageRequest) (*wallet.GetSignTransactionMessageResponse, error)
 	BuildAndSignTransaction(ctx context.Context, req *wallet.BuildAndSignTransactionRequest) (*wallet.BuildAndSignTransactionResponse, error)
 	BuildAndSignBatchTransaction(ctx context.Context, req *wallet.BuildAndSignBatchTransactionRequest) (*wallet.BuildAndSignBatchTransactionResponse, error)
+	SignTypedData(ctx context.Context, req *wallet.SignTypedDataRequest) (*wallet.SignTypedDataResponse, error)
+	SignPersonalMessage(ctx context.Context, req *wallet.SignPersonalMessageRequest) (*wallet.SignPersonalMessageResponse, error)
+	SignPermit(ctx context.Context, req *wallet.SignPermitRequest) (*wallet.SignPermitResponse, error)
+	DecodeTransaction(ctx context.Context, req *wallet.DecodeTransactionRequest) (*wallet.DecodeTransactionResponse, error)
+	SignSafeTransaction(ctx context.Context, req *wallet.SignSafeTransactionRequest) (*wallet.SignSafeTransactionResponse, error)
+	SignUserOperation(ctx context.Context, req *wallet.SignUserOperationRequest) (*wallet.SignUserOperationResponse, error)
+	SignSiweMessage(ctx context.Context, req *wallet.SignSiweMessageRequest) (*wallet.SignSiweMessageResponse, error)
+	SignFlashbotsBundle(ctx context.Context, req *wallet.SignFlashbotsBundleRequest) (*wallet.SignFlashbotsBundleResponse, error)
 }
@@ -0,0 +1,446 @@
+package ethereum
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var methodNameRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// BuildContractCallData 按方法签名（如 "deposit(bytes32,uint256)"）与 JSON 数组形式的参数做 ABI 编码，返回 selector || args
+func BuildContractCallData(method string, args json.RawMessage) ([]byte, error) {
+	name, arguments, err := ParseMethodSignature(method)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := PackArguments(arguments, args)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s arguments fail: %w", name, err)
+	}
+	data := make([]byte, 0, 4+len(packed))
+	data = append(data, MethodSelector(name, arguments)...)
+	data = append(data, packed...)
+	return data, nil
+}
+
+// MethodSelector 用规范化后的参数类型计算 4 字节 selector，例如 uint 会按 uint256 计算
+func MethodSelector(name string, arguments abi.Arguments) []byte {
+	typeNames := make([]string, len(arguments))
+	for i, argument := range arguments {
+		typeNames[i] = argument.Type.String()
+	}
+	return crypto.Keccak256([]byte(name + "(" + strings.Join(typeNames, ",") + ")"))[:4]
+}
+
+// PackArguments 把 JSON 数组中的参数逐个转换成 abi 需要的 Go 类型后编码，不带 selector
+func PackArguments(arguments abi.Arguments, args json.RawMessage) ([]byte, error) {
+	var rawArgs []json.RawMessage
+	if len(bytes.TrimSpace(args)) > 0 {
+		if err := json.Unmarshal(args, &rawArgs); err != nil {
+			return nil, errors.New("args must be a json array")
+		}
+	}
+	if len(rawArgs) != len(arguments) {
+		return nil, fmt.Errorf("argument count mismatch: want %d, got %d", len(arguments), len(rawArgs))
+	}
+	values := make([]interface{}, len(arguments))
+	for i, argument := range arguments {
+		value, err := abiValue(argument.Type, rawArgs[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, argument.Type.String(), err)
+		}
+		values[i] = value.Interface()
+	}
+	return arguments.Pack(values...)
+}
+
+// ParseMethodSignature 解析 "name(type1,type2)" 形式的签名，支持数组、tuple 以及带参数名的写法
+func ParseMethodSignature(signature string) (string, abi.Arguments, error) {
+	signature = strings.TrimSpace(signature)
+	open := strings.Index(signature, "(")
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return "", nil, fmt.Errorf("invalid method signature: %s", signature)
+	}
+	name := strings.TrimSpace(signature[:open])
+	if !methodNameRegex.MatchString(name) {
+		return "", nil, fmt.Errorf("invalid method name: %s", name)
+	}
+	params, err := splitTypeList(signature[open+1 : len(signature)-1])
+	if err != nil {
+		return "", nil, err
+	}
+	arguments := make(abi.Arguments, 0, len(params))
+	for _, param := range params {
+		marshaling, err := parseTypeMarshaling(param, "")
+		if err != nil {
+			return "", nil, err
+		}
+		typ, err := abi.NewType(marshaling.Type, "", marshaling.Components)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid argument type %s: %w", param, err)
+		}
+		arguments = append(arguments, abi.Argument{Type: typ})
+	}
+	return name, arguments, nil
+}
+
+// splitTypeList 按最外层逗号切分参数列表，括号内的 tuple 成员不切分
+func splitTypeList(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, ch := range list {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %s", list)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %s", list)
+	}
+	parts = append(parts, strings.TrimSpace(list[start:]))
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("empty type in %s", list)
+		}
+	}
+	return parts, nil
+}
+
+// parseTypeMarshaling 把单个参数类型转换成 abi.ArgumentMarshaling，tuple 的成员按位置命名为 field0、field1…
+func parseTypeMarshaling(param string, name string) (abi.ArgumentMarshaling, error) {
+	if strings.HasPrefix(param, "(") {
+		closeIdx := matchingParen(param)
+		if closeIdx < 0 {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("unbalanced parentheses in %s", param)
+		}
+		members, err := splitTypeList(param[1:closeIdx])
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		if len(members) == 0 {
+			return abi.ArgumentMarshaling{}, errors.New("empty tuple is not supported")
+		}
+		components := make([]abi.ArgumentMarshaling, 0, len(members))
+		for i, member := range members {
+			component, err := parseTypeMarshaling(member, fmt.Sprintf("field%d", i))
+			if err != nil {
+				return abi.ArgumentMarshaling{}, err
+			}
+			components = append(components, component)
+		}
+		// 去掉 tuple 后面可能带的参数名，只保留数组后缀
+		suffix := strings.Fields(param[closeIdx+1:])
+		arraySuffix := ""
+		if len(suffix) > 0 && strings.HasPrefix(suffix[0], "[") {
+			arraySuffix = suffix[0]
+		}
+		return abi.ArgumentMarshaling{Name: name, Type: "tuple" + arraySuffix, Components: components}, nil
+	}
+	// "uint256 amount" 这种带参数名的写法只取类型部分
+	typeName := strings.Fields(param)[0]
+	return abi.ArgumentMarshaling{Name: name, Type: normalizeTypeName(typeName)}, nil
+}
+
+func matchingParen(s string) int {
+	depth := 0
+	for i, ch := range s {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// normalizeTypeName 把 Solidity 的别名 uint / int 规范成 uint256 / int256
+func normalizeTypeName(typeName string) string {
+	base, suffix := typeName, ""
+	if i := strings.Index(typeName, "["); i >= 0 {
+		base, suffix = typeName[:i], typeName[i:]
+	}
+	switch base {
+	case "uint", "int":
+		base += "256"
+	}
+	return base + suffix
+}
+
+// abiValue 把 JSON 值转换成 abi.Type 对应的 Go 值：整数接受十进制/0x 十六进制字符串或数字，字节类型接受 0x hex
+func abiValue(typ abi.Type, raw json.RawMessage) (reflect.Value, error) {
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := parseJsonInteger(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if err := checkIntegerRange(typ, n); err != nil {
+			return reflect.Value{}, err
+		}
+		// go-ethereum 只把 8/16/32/64 位映射成原生整数，uint24、int40 等其他位宽与 >64 位一样用 *big.Int
+		switch typ.Size {
+		case 8, 16, 32, 64:
+		default:
+			return reflect.ValueOf(n), nil
+		}
+		if typ.T == abi.UintTy {
+			return reflect.ValueOf(n.Uint64()).Convert(typ.GetType()), nil
+		}
+		return reflect.ValueOf(n.Int64()).Convert(typ.GetType()), nil
+	case abi.BoolTy:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return reflect.Value{}, errors.New("expect a bool")
+		}
+		return reflect.ValueOf(b), nil
+	case abi.StringTy:
+		var str string
+		if err := json.Unmarshal(raw, &str); err != nil {
+			return reflect.Value{}, errors.New("expect a string")
+		}
+		return reflect.ValueOf(str), nil
+	case abi.AddressTy:
+		var addr string
+		if err := json.Unmarshal(raw, &addr); err != nil || !common.IsHexAddress(addr) {
+			return reflect.Value{}, errors.New("expect a hex address")
+		}
+		return reflect.ValueOf(common.HexToAddress(addr)), nil
+	case abi.BytesTy:
+		b, err := parseJsonBytes(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(b), nil
+	case abi.FixedBytesTy:
+		b, err := parseJsonBytes(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if len(b) != typ.Size {
+			return reflect.Value{}, fmt.Errorf("expect %d bytes, got %d", typ.Size, len(b))
+		}
+		value := reflect.New(typ.GetType()).Elem()
+		reflect.Copy(value, reflect.ValueOf(b))
+		return value, nil
+	case abi.SliceTy, abi.ArrayTy:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return reflect.Value{}, errors.New("expect a json array")
+		}
+		var value reflect.Value
+		if typ.T == abi.SliceTy {
+			value = reflect.MakeSlice(typ.GetType(), len(elems), len(elems))
+		} else {
+			if len(elems) != typ.Size {
+				return reflect.Value{}, fmt.Errorf("expect %d elements, got %d", typ.Size, len(elems))
+			}
+			value = reflect.New(typ.GetType()).Elem()
+		}
+		for i, elem := range elems {
+			elemValue, err := abiValue(*typ.Elem, elem)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			value.Index(i).Set(elemValue)
+		}
+		return value, nil
+	case abi.TupleTy:
+		var fields []json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil || len(fields) != len(typ.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("expect a json array of %d tuple fields", len(typ.TupleElems))
+		}
+		value := reflect.New(typ.GetType()).Elem()
+		for i, field := range fields {
+			fieldValue, err := abiValue(*typ.TupleElems[i], field)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("tuple field %d: %w", i, err)
+			}
+			value.Field(i).Set(fieldValue)
+		}
+		return value, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported abi type: %s", typ.String())
+	}
+}
+
+func parseJsonInteger(raw json.RawMessage) (*big.Int, error) {
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err != nil {
+		var str string
+		if err := json.Unmarshal(raw, &str); err != nil {
+			return nil, errors.New("expect an integer")
+		}
+		number = json.Number(str)
+	}
+	// 只接受十进制或 0x 十六进制，不接受 SetString(base 0) 额外支持的 0b/0o 前缀与 _ 分隔符
+	digits, base := strings.TrimPrefix(number.String(), "-"), 10
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		digits, base = digits[2:], 16
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok || digits == "" || digits[0] == '+' || digits[0] == '-' {
+		return nil, fmt.Errorf("invalid integer: %s", number)
+	}
+	if strings.HasPrefix(number.String(), "-") {
+		n.Neg(n)
+	}
+	return n, nil
+}
+
+func checkIntegerRange(typ abi.Type, n *big.Int) error {
+	if typ.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > typ.Size {
+			return fmt.Errorf("%s out of range for %s", n, typ.String())
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(typ.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s out of range for %s", n, typ.String())
+	}
+	return nil
+}
+
+func parseJsonBytes(raw json.RawMessage) ([]byte, error) {
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		return nil, errors.New("expect a 0x hex string")
+	}
+	b, err := hexutil.Decode(str)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex bytes: %w", err)
+	}
+	return b, nil
+}
+
+// 代币合约标准
+const (
+	TokenStandardErc20        = "erc20"
+	TokenStandardErc721       = "erc721"
+	TokenStandardErc1155      = "erc1155"
+	TokenStandardErc20Or721   = "erc20_or_erc721"
+	TokenStandardErc721Or1155 = "erc721_or_erc1155"
+)
+
+// TokenCall 是从 calldata 中识别出的代币合约调用
+type TokenCall struct {
+	Standard string
+	Method   string
+	From     *common.Address
+	To       common.Address
+	Amount   *big.Int
+	TokenId  *big.Int
+	Ids      []*big.Int
+	Amounts  []*big.Int
+	Approved bool
+	Data     []byte
+}
+
+type tokenMethod struct {
+	standard  string
+	name      string
+	arguments abi.Arguments
+	decode    func(values []interface{}) *TokenCall
+}
+
+// tokenMethods 按 selector 索引常见的 ERC-20/721/1155 方法；transferFrom 与 setApprovalForAll 的 selector 在多个标准间共用
+var tokenMethods = func() map[[4]byte]tokenMethod {
+	methods := []struct {
+		standard  string
+		signature string
+		decode    func(values []interface{}) *TokenCall
+	}{
+		{TokenStandardErc20, "transfer(address,uint256)", func(v []interface{}) *TokenCall {
+			return &TokenCall{To: v[0].(common.Address), Amount: v[1].(*big.Int)}
+		}},
+		{TokenStandardErc20, "approve(address,uint256)", func(v []interface{}) *TokenCall {
+			return &TokenCall{To: v[0].(common.Address), Amount: v[1].(*big.Int)}
+		}},
+		{TokenStandardErc20Or721, "transferFrom(address,address,uint256)", func(v []interface{}) *TokenCall {
+			from := v[0].(common.Address)
+			return &TokenCall{From: &from, To: v[1].(common.Address), Amount: v[2].(*big.Int)}
+		}},
+		{TokenStandardErc721, "safeTransferFrom(address,address,uint256)", func(v []interface{}) *TokenCall {
+			from := v[0].(common.Address)
+			return &TokenCall{From: &from, To: v[1].(common.Address), TokenId: v[2].(*big.Int)}
+		}},
+		{TokenStandardErc721, "safeTransferFrom(address,address,uint256,bytes)", func(v []interface{}) *TokenCall {
+			from := v[0].(common.Address)
+			return &TokenCall{From: &from, To: v[1].(common.Address), TokenId: v[2].(*big.Int), Data: v[3].([]byte)}
+		}},
+		{TokenStandardErc721Or1155, "setApprovalForAll(address,bool)", func(v []interface{}) *TokenCall {
+			return &TokenCall{To: v[0].(common.Address), Approved: v[1].(bool)}
+		}},
+		{TokenStandardErc1155, "safeTransferFrom(address,address,uint256,uint256,bytes)", func(v []interface{}) *TokenCall {
+			from := v[0].(common.Address)
+			return &TokenCall{From: &from, To: v[1].(common.Address), TokenId: v[2].(*big.Int), Amount: v[3].(*big.Int), Data: v[4].([]byte)}
+		}},
+		{TokenStandardErc1155, "safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)", func(v []interface{}) *TokenCall {
+			from := v[0].(common.Address)
+			return &TokenCall{From: &from, To: v[1].(common.Address), Ids: v[2].([]*big.Int), Amounts: v[3].([]*big.Int), Data: v[4].([]byte)}
+		}},
+	}
+	index := make(map[[4]byte]tokenMethod, len(methods))
+	for _, method := range methods {
+		name, arguments, err := ParseMethodSignature(method.signature)
+		if err != nil {
+			panic(err)
+		}
+		var selector [4]byte
+		copy(selector[:], MethodSelector(name, arguments))
+		index[selector] = tokenMethod{standard: method.standard, name: name, arguments: arguments, decode: method.decode}
+	}
+	return index
+}()
+
+// DecodeTokenCall 识别 ERC-20/721/1155 的转账与授权调用；selector 不在列表中时返回 nil, nil
+func DecodeTokenCall(data []byte) (*TokenCall, error) {
+	if len(data) < 4 {
+		return nil, nil
+	}
+	var selector [4]byte
+	copy(selector[:], data[:4])
+	method, ok := tokenMethods[selector]
+	if !ok {
+		return nil, nil
+	}
+	values, err := method.arguments.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("unpack %s arguments fail: %w", method.name, err)
+	}
+	call := method.decode(values)
+	call.Standard = method.standard
+	call.Method = method.name
+	return call, nil
+}
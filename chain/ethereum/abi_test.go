@@ -0,0 +1,131 @@
+package ethereum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestBuildContractCallData(t *testing.T) {
+	contract, err := abi.JSON(strings.NewReader(`[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]},
+		{"type":"function","name":"deposit","inputs":[
+			{"name":"id","type":"bytes32"},{"name":"fee","type":"uint8"},{"name":"ok","type":"bool"},
+			{"name":"memo","type":"string"},{"name":"path","type":"address[]"},{"name":"raw","type":"bytes"}]},
+		{"type":"function","name":"swap","inputs":[{"name":"order","type":"tuple","components":[
+			{"name":"maker","type":"address"},{"name":"amounts","type":"uint256[2]"}]}]}
+	]`))
+	if err != nil {
+		t.Fatalf("parse abi: %v", err)
+	}
+	to := common.HexToAddress(testToAddress)
+	id := common.HexToHash("0x01")
+
+	cases := []struct {
+		method string
+		args   string
+		want   func() ([]byte, error)
+	}{
+		{
+			method: "transfer(address,uint)",
+			args:   `["` + testToAddress + `", "1000000"]`,
+			want:   func() ([]byte, error) { return contract.Pack("transfer", to, big.NewInt(1_000_000)) },
+		},
+		{
+			method: "deposit(bytes32 id, uint8 fee, bool ok, string memo, address[] path, bytes raw)",
+			args:   `["` + id.Hex() + `", 3, true, "hi", ["` + testToAddress + `"], "0xcafe"]`,
+			want: func() ([]byte, error) {
+				return contract.Pack("deposit", [32]byte(id), uint8(3), true, "hi", []common.Address{to}, []byte{0xca, 0xfe})
+			},
+		},
+		{
+			method: "swap((address,uint256[2]))",
+			args:   `[["` + testToAddress + `", ["0x10", 32]]]`,
+			want: func() ([]byte, error) {
+				return contract.Pack("swap", struct {
+					Maker   common.Address
+					Amounts [2]*big.Int
+				}{to, [2]*big.Int{big.NewInt(16), big.NewInt(32)}})
+			},
+		},
+	}
+	for _, tc := range cases {
+		got, err := BuildContractCallData(tc.method, json.RawMessage(tc.args))
+		if err != nil {
+			t.Fatalf("%s: build call data: %v", tc.method, err)
+		}
+		want, err := tc.want()
+		if err != nil {
+			t.Fatalf("%s: abi pack: %v", tc.method, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: call data mismatch:\n got %x\nwant %x", tc.method, got, want)
+		}
+	}
+}
+
+func TestBuildContractCallDataRejects(t *testing.T) {
+	cases := map[string][2]string{
+		"bad signature":   {"transfer address", `[]`},
+		"arg count":       {"transfer(address,uint256)", `["` + testToAddress + `"]`},
+		"uint8 overflow":  {"f(uint8)", `[256]`},
+		"negative uint":   {"f(uint256)", `["-1"]`},
+		"short bytes32":   {"f(bytes32)", `["0x01"]`},
+		"bad address":     {"f(address)", `["0x1234"]`},
+		"args not array":  {"f(uint256)", `{"a": 1}`},
+		"uint24 overflow": {"f(uint24)", `[16777216]`},
+		"int24 overflow":  {"f(int24)", `[8388608]`},
+		"int24 underflow": {"f(int24)", `["-8388609"]`},
+		"uint40 negative": {"f(uint40)", `["-1"]`},
+		"underscore":      {"f(uint256)", `["1_000"]`},
+		"binary":          {"f(uint256)", `["0b101"]`},
+		"octal":           {"f(uint256)", `["0o17"]`},
+		"plus sign":       {"f(uint256)", `["+1"]`},
+		"empty hex":       {"f(uint256)", `["0x"]`},
+	}
+	for name, tc := range cases {
+		if _, err := BuildContractCallData(tc[0], json.RawMessage(tc[1])); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// go-ethereum 对 8/16/32/64 以外的位宽使用 *big.Int，这些位宽以前会在 reflect Convert 时 panic
+func TestBuildContractCallDataOddIntegerSizes(t *testing.T) {
+	cases := []struct {
+		method string
+		args   string
+		want   string
+	}{
+		{"f(uint24)", `[3000]`, "0xbb8"},
+		{"f(uint24)", `["0xffffff"]`, "0xffffff"},
+		{"f(int24)", `[-887272]`, "-0xd89e8"},
+		{"f(int24)", `["8388607"]`, "0x7fffff"},
+		{"f(uint40)", `[1099511627775]`, "0xffffffffff"},
+		{"f(uint56)", `["72057594037927935"]`, "0xffffffffffffff"},
+		{"f(int8)", `[-128]`, "-0x80"},
+		{"f(uint64)", `["18446744073709551615"]`, "0xffffffffffffffff"},
+	}
+	for _, tc := range cases {
+		data, err := BuildContractCallData(tc.method, json.RawMessage(tc.args))
+		if err != nil {
+			t.Errorf("%s %s: %v", tc.method, tc.args, err)
+			continue
+		}
+		_, arguments, _ := ParseMethodSignature(tc.method)
+		values, err := arguments.Unpack(data[4:])
+		if err != nil {
+			t.Errorf("%s %s: unpack: %v", tc.method, tc.args, err)
+			continue
+		}
+		if got := fmt.Sprintf("%#x", values[0]); got != tc.want {
+			t.Errorf("%s %s: got %s, want %s", tc.method, tc.args, got, tc.want)
+		}
+	}
+}
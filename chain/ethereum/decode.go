@@ -0,0 +1,266 @@
+package ethereum
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+// 以下结构对应未签名 typed transaction 的 RLP 字段（即 ethers / foundry 输出的 unsigned serialization，不含 v、r、s）
+type unsignedAccessListEnvelope struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+type unsignedDynamicFeeEnvelope struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+type unsignedBlobEnvelope struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasTipCap  *uint256.Int
+	GasFeeCap  *uint256.Int
+	Gas        uint64
+	To         common.Address
+	Value      *uint256.Int
+	Data       []byte
+	AccessList types.AccessList
+	BlobFeeCap *uint256.Int
+	BlobHashes []common.Hash
+}
+
+type unsignedSetCodeEnvelope struct {
+	ChainID    *uint256.Int
+	Nonce      uint64
+	GasTipCap  *uint256.Int
+	GasFeeCap  *uint256.Int
+	Gas        uint64
+	To         common.Address
+	Value      *uint256.Int
+	Data       []byte
+	AccessList types.AccessList
+	AuthList   []types.SetCodeAuthorization
+}
+
+// unsignedLegacyEnvelope 是 EIP-155 的未签名 legacy 交易：[nonce, gasPrice, gas, to, value, data, chainId, 0, 0]
+type unsignedLegacyEnvelope struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+	ChainID  *big.Int `rlp:"optional"`
+	R        *big.Int `rlp:"optional"`
+	S        *big.Int `rlp:"optional"`
+}
+
+// DecodedTx 是解码后的交易；chainID 对未签名交易来自 envelope 本身，对已签名的 legacy 交易由 v 推导
+type DecodedTx struct {
+	Tx      *types.Transaction
+	ChainID *big.Int
+	Signed  bool
+}
+
+// DecodeRawTxBytes 把 0x hex、不带前缀的 hex 或 base64 编码的交易解码成字节
+func DecodeRawTxBytes(raw string) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.New("raw transaction is empty")
+	}
+	if strings.HasPrefix(raw, "0x") || strings.HasPrefix(raw, "0X") {
+		b, err := hex.DecodeString(raw[2:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex transaction: %w", err)
+		}
+		return b, nil
+	}
+	// 纯 hex 字符恰好也是合法的 base64，所以优先按 hex 解析
+	if b, err := hex.DecodeString(raw); err == nil {
+		return b, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errors.New("raw transaction must be hex or base64 encoded")
+	}
+	return b, nil
+}
+
+// DecodeRawTransaction 解码已签名或未签名的交易，legacy RLP 与 EIP-2718 typed envelope 均可
+func DecodeRawTransaction(raw []byte) (*DecodedTx, error) {
+	if len(raw) == 0 {
+		return nil, errors.New("raw transaction is empty")
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err == nil {
+		v, r, s := tx.RawSignatureValues()
+		if r.Sign() != 0 || s.Sign() != 0 {
+			return &DecodedTx{Tx: tx, ChainID: tx.ChainId(), Signed: true}, nil
+		}
+		// v、r、s 为 0 的占位签名按未签名处理；EIP-155 legacy 的 v 即 chainId
+		if tx.Type() == types.LegacyTxType {
+			return decodedLegacy(tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), v), nil
+		}
+		return &DecodedTx{Tx: tx, ChainID: tx.ChainId()}, nil
+	}
+	if raw[0] >= 0xc0 {
+		var envelope unsignedLegacyEnvelope
+		if err := rlp.DecodeBytes(raw, &envelope); err != nil {
+			return nil, fmt.Errorf("decode legacy transaction fail: %w", err)
+		}
+		return decodedLegacy(envelope.Nonce, envelope.GasPrice, envelope.Gas, envelope.To, envelope.Value, envelope.Data, envelope.ChainID), nil
+	}
+	return decodeUnsignedTyped(raw[0], raw[1:])
+}
+
+func decodedLegacy(nonce uint64, gasPrice *big.Int, gas uint64, to *common.Address, value *big.Int, data []byte, chainID *big.Int) *DecodedTx {
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      gas,
+		To:       to,
+		Value:    value,
+		Data:     data,
+	})
+	if chainID == nil {
+		chainID = new(big.Int)
+	}
+	return &DecodedTx{Tx: tx, ChainID: chainID}
+}
+
+func decodeUnsignedTyped(txType byte, payload []byte) (*DecodedTx, error) {
+	var txData types.TxData
+	switch txType {
+	case types.AccessListTxType:
+		var envelope unsignedAccessListEnvelope
+		if err := rlp.DecodeBytes(payload, &envelope); err != nil {
+			return nil, fmt.Errorf("decode access list transaction fail: %w", err)
+		}
+		txData = &types.AccessListTx{
+			ChainID:    envelope.ChainID,
+			Nonce:      envelope.Nonce,
+			GasPrice:   envelope.GasPrice,
+			Gas:        envelope.Gas,
+			To:         envelope.To,
+			Value:      envelope.Value,
+			Data:       envelope.Data,
+			AccessList: envelope.AccessList,
+		}
+	case types.DynamicFeeTxType:
+		var envelope unsignedDynamicFeeEnvelope
+		if err := rlp.DecodeBytes(payload, &envelope); err != nil {
+			return nil, fmt.Errorf("decode dynamic fee transaction fail: %w", err)
+		}
+		txData = &types.DynamicFeeTx{
+			ChainID:    envelope.ChainID,
+			Nonce:      envelope.Nonce,
+			GasTipCap:  envelope.GasTipCap,
+			GasFeeCap:  envelope.GasFeeCap,
+			Gas:        envelope.Gas,
+			To:         envelope.To,
+			Value:      envelope.Value,
+			Data:       envelope.Data,
+			AccessList: envelope.AccessList,
+		}
+	case types.BlobTxType:
+		var envelope unsignedBlobEnvelope
+		if err := rlp.DecodeBytes(payload, &envelope); err != nil {
+			return nil, fmt.Errorf("decode blob transaction fail: %w", err)
+		}
+		txData = &types.BlobTx{
+			ChainID:    envelope.ChainID,
+			Nonce:      envelope.Nonce,
+			GasTipCap:  envelope.GasTipCap,
+			GasFeeCap:  envelope.GasFeeCap,
+			Gas:        envelope.Gas,
+			To:         envelope.To,
+			Value:      envelope.Value,
+			Data:       envelope.Data,
+			AccessList: envelope.AccessList,
+			BlobFeeCap: envelope.BlobFeeCap,
+			BlobHashes: envelope.BlobHashes,
+		}
+	case types.SetCodeTxType:
+		var envelope unsignedSetCodeEnvelope
+		if err := rlp.DecodeBytes(payload, &envelope); err != nil {
+			return nil, fmt.Errorf("decode set code transaction fail: %w", err)
+		}
+		txData = &types.SetCodeTx{
+			ChainID:    envelope.ChainID,
+			Nonce:      envelope.Nonce,
+			GasTipCap:  envelope.GasTipCap,
+			GasFeeCap:  envelope.GasFeeCap,
+			Gas:        envelope.Gas,
+			To:         envelope.To,
+			Value:      envelope.Value,
+			Data:       envelope.Data,
+			AccessList: envelope.AccessList,
+			AuthList:   envelope.AuthList,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transaction type: %d", txType)
+	}
+	tx := types.NewTx(txData)
+	return &DecodedTx{Tx: tx, ChainID: tx.ChainId()}, nil
+}
+
+// SigningHash 返回交易的待签名 digest；chainID 为 0 的 legacy 交易按 EIP-155 之前的规则计算
+func (d *DecodedTx) SigningHash() common.Hash {
+	return d.signer().Hash(d.Tx)
+}
+
+// Sender 由签名恢复发送方地址，仅对已签名交易有效
+func (d *DecodedTx) Sender() (common.Address, error) {
+	if !d.Signed {
+		return common.Address{}, errors.New("transaction is not signed")
+	}
+	return types.Sender(d.signer(), d.Tx)
+}
+
+func (d *DecodedTx) signer() types.Signer {
+	if d.Tx.Type() == types.LegacyTxType && (d.ChainID == nil || d.ChainID.Sign() == 0) {
+		return types.HomesteadSigner{}
+	}
+	return types.LatestSignerForChainID(d.ChainID)
+}
+
+// TxTypeName 把 EIP-2718 交易类型映射为请求中使用的 tx_type 名称
+func TxTypeName(txType uint8) string {
+	switch txType {
+	case types.LegacyTxType:
+		return TxTypeLegacy
+	case types.AccessListTxType:
+		return TxTypeAccessList
+	case types.DynamicFeeTxType:
+		return TxTypeDynamicFee
+	case types.BlobTxType:
+		return TxTypeBlob
+	case types.SetCodeTxType:
+		return TxTypeSetCode
+	default:
+		return fmt.Sprintf("0x%02x", txType)
+	}
+}
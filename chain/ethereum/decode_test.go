@@ -0,0 +1,232 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+func TestDecodeSignedTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	sender, _ := publicKeyToAddress(pubKey)
+	token := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	body := encodeTxBody(t, Eip1559DynamicFeeTx{
+		ChainId:              "1",
+		Nonce:                9,
+		GasLimit:             60000,
+		MaxFeePerGas:         "30000000000",
+		MaxPriorityFeePerGas: "1000000000",
+		TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "2500000", ContractAddress: token, TokenType: TokenTypeErc20},
+	})
+	signed, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: body})
+	if err != nil || signed.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign fail: resp=%v err=%v", signed, err)
+	}
+
+	// 0x hex、裸 hex 与 base64 三种编码结果一致
+	raw := hexutil.MustDecode(signed.SignedTx)
+	for _, encoded := range []string{signed.SignedTx, signed.SignedTx[2:], base64.StdEncoding.EncodeToString(raw)} {
+		resp, err := c.DecodeTransaction(context.Background(), &wallet.DecodeTransactionRequest{RawTx: encoded})
+		if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Fatalf("decode fail: resp=%v err=%v", resp, err)
+		}
+		if !resp.Signed || resp.TxType != TxTypeDynamicFee || resp.ChainId != "1" || resp.Nonce != 9 {
+			t.Errorf("unexpected fields: %v", resp)
+		}
+		if resp.TxHash != signed.TxHash || resp.SigningHash != signed.TxMessageHash {
+			t.Errorf("hash mismatch: tx %s/%s signing %s/%s", resp.TxHash, signed.TxHash, resp.SigningHash, signed.TxMessageHash)
+		}
+		if resp.Sender != sender.Hex() {
+			t.Errorf("sender: got %s, want %s", resp.Sender, sender.Hex())
+		}
+		call := resp.TokenCall
+		if call == nil || call.Standard != TokenStandardErc20 || call.Method != "transfer" ||
+			!strings.EqualFold(call.Contract, token) || call.To != common.HexToAddress(testToAddress).Hex() || call.Amount != "2500000" {
+			t.Errorf("token call: got %v", call)
+		}
+	}
+}
+
+func TestDecodeUnsignedTransaction(t *testing.T) {
+	to := common.HexToAddress(testToAddress)
+	dynamicFeeTx := &types.DynamicFeeTx{
+		ChainID:   big.NewInt(137),
+		Nonce:     1,
+		GasTipCap: big.NewInt(2),
+		GasFeeCap: big.NewInt(3),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(4),
+	}
+	payload, err := rlp.EncodeToBytes(unsignedDynamicFeeEnvelope{
+		ChainID:   dynamicFeeTx.ChainID,
+		Nonce:     dynamicFeeTx.Nonce,
+		GasTipCap: dynamicFeeTx.GasTipCap,
+		GasFeeCap: dynamicFeeTx.GasFeeCap,
+		Gas:       dynamicFeeTx.Gas,
+		To:        dynamicFeeTx.To,
+		Value:     dynamicFeeTx.Value,
+	})
+	if err != nil {
+		t.Fatalf("encode envelope: %v", err)
+	}
+	decoded, err := DecodeRawTransaction(append([]byte{types.DynamicFeeTxType}, payload...))
+	if err != nil {
+		t.Fatalf("decode unsigned typed tx: %v", err)
+	}
+	want := types.LatestSignerForChainID(big.NewInt(137)).Hash(types.NewTx(dynamicFeeTx))
+	if decoded.Signed || decoded.ChainID.Int64() != 137 || decoded.SigningHash() != want {
+		t.Errorf("unsigned dynamic fee tx: signed=%v chainId=%v signingHash=%s", decoded.Signed, decoded.ChainID, decoded.SigningHash())
+	}
+
+	// EIP-155 未签名 legacy：[nonce, gasPrice, gas, to, value, data, chainId, 0, 0]
+	legacy, _ := rlp.EncodeToBytes([]interface{}{uint64(5), big.NewInt(10), uint64(21000), to, big.NewInt(1), []byte{}, big.NewInt(56), uint(0), uint(0)})
+	decoded, err = DecodeRawTransaction(legacy)
+	if err != nil {
+		t.Fatalf("decode unsigned legacy tx: %v", err)
+	}
+	if decoded.Signed || decoded.ChainID.Int64() != 56 || decoded.Tx.Nonce() != 5 {
+		t.Errorf("unsigned legacy tx: signed=%v chainId=%v nonce=%d", decoded.Signed, decoded.ChainID, decoded.Tx.Nonce())
+	}
+	if _, err := decoded.Sender(); err == nil {
+		t.Errorf("unsigned tx must not have a sender")
+	}
+}
+
+func TestDecodeTokenCall(t *testing.T) {
+	from := common.HexToAddress("0x2f2a5B199438e31d46dEf97d90A51ac80c233bfF")
+	to := common.HexToAddress(testToAddress)
+	erc721, _ := BuildErc721Data(from, to, big.NewInt(7))
+	erc1155, _ := BuildErc1155Data(from, to, big.NewInt(3), big.NewInt(10), []byte{1})
+
+	call, err := DecodeTokenCall(erc721)
+	if err != nil || call == nil || call.Standard != TokenStandardErc721 || *call.From != from || call.To != to || call.TokenId.Int64() != 7 {
+		t.Errorf("erc721: got %+v err=%v", call, err)
+	}
+	call, err = DecodeTokenCall(erc1155)
+	if err != nil || call == nil || call.Standard != TokenStandardErc1155 || call.TokenId.Int64() != 3 || call.Amount.Int64() != 10 {
+		t.Errorf("erc1155: got %+v err=%v", call, err)
+	}
+	if call, err := DecodeTokenCall([]byte{0xde, 0xad, 0xbe, 0xef}); call != nil || err != nil {
+		t.Errorf("unknown selector: got %+v err=%v", call, err)
+	}
+	if _, err := DecodeTokenCall(erc721[:20]); err == nil {
+		t.Errorf("truncated calldata must fail")
+	}
+}
+
+func TestDecodeTransactionWithMalformedTokenCall(t *testing.T) {
+	c, _ := newTestAdaptor(t)
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress(testToAddress)
+	// transfer 选择器后只跟 2 字节，参数无法解析
+	tx, _ := types.SignNewTx(key, types.LatestSignerForChainID(common.Big1), &types.DynamicFeeTx{
+		ChainID:   common.Big1,
+		Nonce:     1,
+		Gas:       60000,
+		GasFeeCap: big.NewInt(30000000000),
+		GasTipCap: big.NewInt(1000000000),
+		To:        &to,
+		Data:      hexutil.MustDecode("0xa9059cbbbeef"),
+	})
+	raw, _ := tx.MarshalBinary()
+
+	resp, err := c.DecodeTransaction(context.Background(), &wallet.DecodeTransactionRequest{RawTx: hexutil.Encode(raw)})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("malformed token calldata must still decode: resp=%v err=%v", resp, err)
+	}
+	if resp.TokenCall != nil || resp.TxHash != tx.Hash().Hex() || resp.Sender != crypto.PubkeyToAddress(key.PublicKey).Hex() || resp.Data != "0xa9059cbbbeef" {
+		t.Errorf("unexpected decode result: %v", resp)
+	}
+}
+
+func TestBuildAndSignUnsignedEnvelope(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{Name: "Polygon", ChainId: 137, MaxFeePerGas: "1000000000000"})
+	to := common.HexToAddress(testToAddress)
+	dynamicFee := func(chainID int64, gasFeeCap int64) []byte {
+		payload, err := rlp.EncodeToBytes(unsignedDynamicFeeEnvelope{
+			ChainID:   big.NewInt(chainID),
+			Nonce:     3,
+			GasTipCap: big.NewInt(1000000000),
+			GasFeeCap: big.NewInt(gasFeeCap),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(1),
+		})
+		if err != nil {
+			t.Fatalf("encode envelope: %v", err)
+		}
+		return append([]byte{types.DynamicFeeTxType}, payload...)
+	}
+	envelope := dynamicFee(137, 30000000000)
+
+	resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(envelope)})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign unsigned envelope fail: resp=%v err=%v", resp, err)
+	}
+	tx := decodeSignedTx(t, resp.SignedTx)
+	if tx.Type() != types.DynamicFeeTxType || tx.ChainId().Int64() != 137 || tx.Nonce() != 3 || *tx.To() != to {
+		t.Fatalf("signed tx does not match envelope: %+v", tx)
+	}
+	assertSender(t, tx, pubKey)
+	decoded, _ := DecodeRawTransaction(envelope)
+	if resp.TxMessageHash != decoded.SigningHash().Hex() {
+		t.Errorf("tx message hash: got %s, want %s", resp.TxMessageHash, decoded.SigningHash().Hex())
+	}
+	// base64 编码的同一 envelope 得到同一笔交易
+	again, _ := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: base64.StdEncoding.EncodeToString(envelope)})
+	if again.Code != wallet.ReturnCode_SUCCESS || again.TxHash != resp.TxHash {
+		t.Errorf("base64 envelope: got %v", again)
+	}
+
+	legacy, _ := rlp.EncodeToBytes([]interface{}{uint64(4), big.NewInt(30000000000), uint64(21000), to, big.NewInt(1), []byte{}, big.NewInt(137), uint(0), uint(0)})
+	legacyResp, _ := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(legacy)})
+	if legacyResp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign unsigned legacy envelope fail: %s", legacyResp.Message)
+	}
+	if legacyTx := decodeSignedTx(t, legacyResp.SignedTx); !legacyTx.Protected() || legacyTx.ChainId().Int64() != 137 {
+		t.Errorf("legacy envelope must be signed with EIP-155")
+	}
+
+	blobPayload, _ := rlp.EncodeToBytes(unsignedBlobEnvelope{
+		ChainID:    uint256.NewInt(137),
+		GasTipCap:  uint256.NewInt(1),
+		GasFeeCap:  uint256.NewInt(1),
+		Gas:        21000,
+		To:         to,
+		Value:      uint256.NewInt(0),
+		BlobFeeCap: uint256.NewInt(1),
+		BlobHashes: []common.Hash{{0x01}},
+	})
+	preEip155, _ := rlp.EncodeToBytes([]interface{}{uint64(5), big.NewInt(1), uint64(21000), to, big.NewInt(1), []byte{}})
+	cases := map[string]struct {
+		req    *wallet.BuildAndSignTransactionRequest
+		want   string
+		reason wallet.RejectReason
+	}{
+		"wrong chain":      {&wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(dynamicFee(1, 30000000000))}, "chain ID does not match network", wallet.RejectReason_REJECT_REASON_CHAIN_ID_MISMATCH},
+		"fee over ceiling": {&wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(dynamicFee(137, 2000000000000))}, "exceeds Polygon ceiling", wallet.RejectReason_REJECT_REASON_MAX_FEE_TOO_HIGH},
+		"blob":             {&wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(append([]byte{types.BlobTxType}, blobPayload...))}, "no sidecar", wallet.RejectReason_REJECT_REASON_NONE},
+		"no chain id":      {&wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(preEip155)}, "must carry a chain ID", wallet.RejectReason_REJECT_REASON_NONE},
+		"already signed":   {&wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: resp.SignedTx}, "already signed", wallet.RejectReason_REJECT_REASON_NONE},
+		"both bodies":      {&wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(envelope), TxBase64Body: "e30="}, "mutually exclusive", wallet.RejectReason_REJECT_REASON_NONE},
+		"no public key":    {&wallet.BuildAndSignTransactionRequest{UnsignedTx: hexutil.Encode(envelope)}, "public key or from address is required", wallet.RejectReason_REJECT_REASON_NONE},
+	}
+	for name, tc := range cases {
+		resp, err := c.BuildAndSignTransaction(context.Background(), tc.req)
+		if err != nil || resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, tc.want) || resp.RejectReason != tc.reason {
+			t.Errorf("%s: got resp=%v err=%v, want error containing %q", name, resp, err, tc.want)
+		}
+	}
+}
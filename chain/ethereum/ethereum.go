@@ -2,6 +2,7 @@ package ethereum
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -14,29 +15,66 @@ import (
 	"github.com/Brant-Liang/wallet-sign/leveldb"
 	"github.com/Brant-Liang/wallet-sign/ssm"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/holiman/uint256"
+	"google.golang.org/protobuf/proto"
 	"math/big"
+	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	ChainName            = "Ethereum"
 	maxCreateKeyPairsNum = 10_000
+	maxBatchSignNum      = 1_000
+)
+
+var (
+	errInvalidMessageHash  = errors.New("message hash must be 32 bytes of hex")
+	errPrivateKeyNotFound  = errors.New("private key not found")
+	errInvalidPublicKey    = errors.New("public key is not a secp256k1 key")
+	errAddressNotFound     = errors.New("no public key indexed for address")
+	errFromAddressMismatch = errors.New("from address does not match public key")
 )
 
 type ChainAdaptor struct {
+	conf      *config.Config
 	signer    ssm.Signer
 	db        *leveldb.Keys
 	hsmClient *hsm.HsmClient
+	network   *evmNetwork // 为空时不校验 chain_id 等网络参数
 }
 
 func NewChainAdapter(conf *config.Config, db *leveldb.Keys, hsmClient *hsm.HsmClient) (chain.IChainAdaptor, error) {
+	return NewEvmChainAdapter(conf, conf.EvmNetwork(ChainName), db, hsmClient)
+}
+
+// NewEvmChainAdapter 为 Polygon、BSC 等 EVM 网络创建复用 Ethereum 签名逻辑的适配器
+func NewEvmChainAdapter(conf *config.Config, networkConf *config.EvmNetwork, db *leveldb.Keys, hsmClient *hsm.HsmClient) (chain.IChainAdaptor, error) {
+	for _, delegate := range conf.AuthorizationDelegates {
+		if !common.IsHexAddress(delegate) {
+			return nil, fmt.Errorf("invalid authorization delegate: %s", delegate)
+		}
+	}
+	var network *evmNetwork
+	if networkConf != nil {
+		var err error
+		network, err = newEvmNetwork(networkConf)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &ChainAdaptor{
+		conf:      conf,
 		db:        db,
 		hsmClient: hsmClient,
 		signer:    ssm.NewEcdsaSigner(),
+		network:   network,
 	}, nil
 }
 
@@ -52,25 +90,83 @@ func (c ChainAdaptor) GetChainSchema(ctx context.Context, req *wallet.GetChainSc
 	es := EthereumSchema{
 		RequestId: "0",
 		DynamicFeeTx: Eip1559DynamicFeeTx{
+			TxType:               TxTypeDynamicFee,
 			ChainId:              "",
 			Nonce:                0,
-			FromAddress:          common.Address{}.String(),
-			ToAddress:            common.Address{}.String(),
 			GasLimit:             0,
 			MaxFeePerGas:         "0",
 			MaxPriorityFeePerGas: "0",
-			Amount:               "0",
-			ContractAddress:      "",
+			TxPayload: TxPayload{
+				FromAddress:     common.Address{}.String(),
+				ToAddress:       common.Address{}.String(),
+				Amount:          "0",
+				ContractAddress: "",
+			},
 		},
 		ClassicFeeTx: LegacyFeeTx{
-			ChainId:         "0",
-			Nonce:           0,
-			FromAddress:     common.Address{}.String(),
-			ToAddress:       common.Address{}.String(),
-			GasLimit:        0,
-			GasPrice:        0,
-			Amount:          "0",
-			ContractAddress: "",
+			TxType:   TxTypeLegacy,
+			ChainId:  "0",
+			Nonce:    0,
+			GasLimit: 0,
+			GasPrice: 0,
+			TxPayload: TxPayload{
+				FromAddress:     common.Address{}.String(),
+				ToAddress:       common.Address{}.String(),
+				Amount:          "0",
+				ContractAddress: "",
+			},
+		},
+		BlobTx: Eip4844BlobTx{
+			TxType:               TxTypeBlob,
+			ChainId:              "0",
+			Nonce:                0,
+			GasLimit:             0,
+			MaxFeePerGas:         "0",
+			MaxPriorityFeePerGas: "0",
+			MaxFeePerBlobGas:     "0",
+			Blobs:                []string{"0x"},
+			SidecarVersion:       0,
+			TxPayload: TxPayload{
+				FromAddress: common.Address{}.String(),
+				ToAddress:   common.Address{}.String(),
+				Amount:      "0",
+			},
+		},
+		SetCodeTx: Eip7702SetCodeTx{
+			TxType:               TxTypeSetCode,
+			ChainId:              "0",
+			Nonce:                0,
+			GasLimit:             0,
+			MaxFeePerGas:         "0",
+			MaxPriorityFeePerGas: "0",
+			Authorizations: []Eip7702Authorization{{
+				ChainId:   "0",
+				Address:   common.Address{}.String(),
+				Nonce:     0,
+				PublicKey: "",
+			}},
+			TxPayload: TxPayload{
+				FromAddress: common.Address{}.String(),
+				ToAddress:   common.Address{}.String(),
+				Amount:      "0",
+			},
+		},
+		AccessListTx: Eip2930AccessListTx{
+			TxType:   TxTypeAccessList,
+			ChainId:  "0",
+			Nonce:    0,
+			GasLimit: 0,
+			GasPrice: "0",
+			AccessList: []AccessTuple{{
+				Address:     common.Address{}.String(),
+				StorageKeys: []string{common.Hash{}.String()},
+			}},
+			TxPayload: TxPayload{
+				FromAddress:     common.Address{}.String(),
+				ToAddress:       common.Address{}.String(),
+				Amount:          "0",
+				ContractAddress: "",
+			},
 		},
 	}
 	b, err := json.Marshal(es)
@@ -110,9 +206,15 @@ func (c ChainAdaptor) CreateKeyPairsExportPublicKeyList(ctx context.Context, req
 			resp.Msg = "create key pairs fail"
 			return resp, nil
 		}
+		address, err := publicKeyToAddress(pubKeyStr)
+		if err != nil {
+			resp.Msg = "create key pairs fail"
+			return resp, nil
+		}
 		keyItem := leveldb.Key{
 			PrivateKey: priKeyStr,
 			Pubkey:     pubKeyStr,
+			Address:    address.Hex(),
 		}
 		pukItem := &wallet.PublicKey{
 			CompressPubkey: compressPubkeyStr,
@@ -147,15 +249,17 @@ func (c ChainAdaptor) CreateKeyPairsWithAddresses(ctx context.Context, req *wall
 			resp.Message = "create key pairs fail"
 			return resp, nil
 		}
+		publicKeyBytes, err := hex.DecodeString(pubKeyStr)
+		address := common.BytesToAddress(crypto.Keccak256(publicKeyBytes[1:])[12:]).String()
 		keyItem := leveldb.Key{
 			PrivateKey: priKeyStr,
 			Pubkey:     pubKeyStr,
+			Address:    address,
 		}
-		publicKeyBytes, err := hex.DecodeString(pubKeyStr)
 		pukAddressItem := &wallet.ExportPublicKeyWithAddress{
 			CompressPublicKey: compressPubkeyStr,
 			PublicKey:         pubKeyStr,
-			Address:           common.BytesToAddress(crypto.Keccak256(publicKeyBytes[1:])[12:]).String(),
+			Address:           address,
 		}
 		retKeyWithAddressList = append(retKeyWithAddressList, pukAddressItem)
 		keyList = append(keyList, keyItem)
@@ -170,155 +274,1652 @@ func (c ChainAdaptor) CreateKeyPairsWithAddresses(ctx context.Context, req *wall
 	return resp, nil
 }
 
+// SignTransactionMessage 直接对调用方算好的 32 字节 hash 签名，签名机看不到交易内容；
+// 网络的 fee / gas 限制只作用于由签名机构造交易的 BuildAndSignTransaction 与批量接口
 func (c ChainAdaptor) SignTransactionMessage(ctx context.Context, req *wallet.GetSignTransactionMessageRequest) (*wallet.GetSignTransactionMessageResponse, error) {
-	//TODO implement me
-	panic("implement me")
-}
+	resp := &wallet.GetSignTransactionMessageResponse{Code: wallet.ReturnCode_ERROR}
 
-func (c ChainAdaptor) BuildAndSignBatchTransaction(ctx context.Context, req *wallet.BuildAndSignBatchTransactionRequest) (*wallet.BuildAndSignBatchTransactionResponse, error) {
-	//TODO implement me
-	panic("implement me")
+	digest, err := parseMessageHash(req.MessageHash)
+	if err != nil {
+		log.Error("parse message hash fail", "messageHash", req.MessageHash, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	sig, err := c.signDigest(req.PublicKey, digest)
+	if err != nil {
+		log.Error("sign message hash fail", "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign transaction message success"
+	resp.Signature = hex.EncodeToString(sig)
+	return resp, nil
 }
 
-func (c ChainAdaptor) BuildAndSignTransaction(ctx context.Context, req *wallet.BuildAndSignTransactionRequest) (*wallet.BuildAndSignTransactionResponse, error) {
-	resp := &wallet.BuildAndSignTransactionResponse{Code: wallet.ReturnCode_ERROR}
+func (c ChainAdaptor) SignTypedData(ctx context.Context, req *wallet.SignTypedDataRequest) (*wallet.SignTypedDataResponse, error) {
+	resp := &wallet.SignTypedDataResponse{Code: wallet.ReturnCode_ERROR}
 
-	// 1) 解析 & 构造 tx
-	dFeeTx, _, err := c.buildDynamicFeeTx(req.TxBase64Body)
+	typedData, err := ParseTypedData(req.TypedData)
 	if err != nil {
-		return nil, err
+		log.Error("parse typed data fail", "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	if err := c.network.checkTypedDataDomain(typedData); err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	if err := checkTypedDataPermit(typedData, c.permitPolicy(), time.Now()); err != nil {
+		log.Error("typed data permit rejected by policy", "primaryType", typedData.PrimaryType, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	typedDataHash, err := HashTypedData(typedData)
+	if err != nil {
+		log.Error("hash typed data fail", "primaryType", typedData.PrimaryType, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
 	}
-	// 2) 待签名hash (digest)
-	digest := CreateEip1559UnSignTx(dFeeTx, dFeeTx.ChainID) // 返回 common.Hash
+	sig, err := c.signDigest(req.PublicKey, typedDataHash.Digest)
+	if err != nil {
+		log.Error("sign typed data fail", "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	log.Info("sign typed data success",
+		"primaryType", typedData.PrimaryType,
+		"domain", typedData.Domain.Name,
+		"verifyingContract", typedData.Domain.VerifyingContract,
+		"digest", typedDataHash.Digest,
+	)
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign typed data success"
+	resp.DomainSeparator = typedDataHash.DomainSeparator.Hex()
+	resp.StructHash = typedDataHash.StructHash.Hex()
+	resp.Digest = typedDataHash.Digest.Hex()
+	resp.Signature = hexutil.Encode(toWalletSignature(sig))
+	return resp, nil
+}
 
-	rawTx := digest.Hex() // digest: 对 TxData 规范化编码 + keccak256，结果 32字节
+func (c ChainAdaptor) SignPersonalMessage(ctx context.Context, req *wallet.SignPersonalMessageRequest) (*wallet.SignPersonalMessageResponse, error) {
+	resp := &wallet.SignPersonalMessageResponse{Code: wallet.ReturnCode_ERROR}
 
-	// 取私钥
-	privKey, ok := c.db.GetPrivKey(req.PublicKey)
-	if !ok {
-		log.Error("private key not found for public key")
-		resp.Message = "private key not found"
+	msg, err := DecodePersonalMessage(req.Message, req.MessageEncoding)
+	if err != nil {
+		log.Error("decode personal message fail", "encoding", req.MessageEncoding, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	digest := PersonalMessageHash(msg)
+	sig, err := c.signDigest(req.PublicKey, digest)
+	if err != nil {
+		log.Error("sign personal message fail", "err", err)
+		resp.Message = err.Error()
 		return resp, nil
 	}
+	log.Info("sign personal message success", "messageLen", len(msg), "digest", digest)
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign personal message success"
+	resp.Digest = digest.Hex()
+	resp.Signature = hexutil.Encode(toWalletSignature(sig))
+	return resp, nil
+}
+
+func (c ChainAdaptor) SignPermit(ctx context.Context, req *wallet.SignPermitRequest) (*wallet.SignPermitResponse, error) {
+	resp := &wallet.SignPermitResponse{Code: wallet.ReturnCode_ERROR}
 
-	// 4) 签名（约定 SignMessage 接收 32字节哈希的 hex 字符串，不带0x）
-	//    如果需要，去掉 0x：hex.EncodeToString(digest[:])
-	signature, err3 := c.signer.SignMessage(privKey, rawTx)
-	if err3 != nil {
-		log.Error("sign transaction fail", "err", err3)
-		resp.Message = "sign transaction fail"
+	owner, err := publicKeyToAddress(req.PublicKey)
+	if err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	typedData, err := buildPermitTypedData(req, owner, c.permitPolicy(), time.Now())
+	if err != nil {
+		log.Error("build permit fail", "permitType", req.PermitType, "spender", req.Spender, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	if err := c.network.checkTypedDataDomain(typedData); err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	typedDataHash, err := HashTypedData(typedData)
+	if err != nil {
+		log.Error("hash permit fail", "permitType", req.PermitType, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	typedDataJson, err := json.Marshal(typedData)
+	if err != nil {
+		resp.Message = err.Error()
 		return resp, nil
 	}
+	sig, err := c.signDigest(req.PublicKey, typedDataHash.Digest)
+	if err != nil {
+		log.Error("sign permit fail", "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	walletSig := toWalletSignature(sig)
+	log.Info("sign permit success",
+		"permitType", req.PermitType,
+		"owner", owner,
+		"spender", req.Spender,
+		"verifyingContract", typedData.Domain.VerifyingContract,
+		"deadline", req.Deadline,
+		"digest", typedDataHash.Digest,
+	)
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign permit success"
+	resp.TypedData = string(typedDataJson)
+	resp.Digest = typedDataHash.Digest.Hex()
+	resp.Signature = hexutil.Encode(walletSig)
+	resp.R = hexutil.Encode(walletSig[:32])
+	resp.S = hexutil.Encode(walletSig[32:64])
+	resp.V = uint32(walletSig[crypto.RecoveryIDOffset])
+	return resp, nil
+}
+
+func (c ChainAdaptor) DecodeTransaction(ctx context.Context, req *wallet.DecodeTransactionRequest) (*wallet.DecodeTransactionResponse, error) {
+	resp := &wallet.DecodeTransactionResponse{Code: wallet.ReturnCode_ERROR}
 
-	inputSignatureByteList, err := hex.DecodeString(signature)
+	raw, err := DecodeRawTxBytes(req.RawTx)
 	if err != nil {
-		log.Error("decode signature failed", "err", err)
-		resp.Message = "decode signature failed"
+		resp.Message = err.Error()
 		return resp, nil
 	}
+	decoded, err := DecodeRawTransaction(raw)
+	if err != nil {
+		log.Error("decode transaction fail", "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	tx := decoded.Tx
+	resp.TxType = TxTypeName(tx.Type())
+	resp.ChainId = decoded.ChainID.String()
+	resp.Nonce = tx.Nonce()
+	if tx.To() != nil {
+		resp.To = tx.To().Hex()
+	}
+	resp.Value = tx.Value().String()
+	resp.GasLimit = tx.Gas()
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType:
+		resp.GasPrice = tx.GasPrice().String()
+	default:
+		resp.MaxFeePerGas = tx.GasFeeCap().String()
+		resp.MaxPriorityFeePerGas = tx.GasTipCap().String()
+	}
+	if tx.Type() == types.BlobTxType {
+		resp.MaxFeePerBlobGas = tx.BlobGasFeeCap().String()
+		for _, blobHash := range tx.BlobHashes() {
+			resp.BlobHashes = append(resp.BlobHashes, blobHash.Hex())
+		}
+	}
+	resp.Data = hexutil.Encode(tx.Data())
+	resp.Signed = decoded.Signed
+	resp.SigningHash = decoded.SigningHash().Hex()
+	if decoded.Signed {
+		resp.TxHash = tx.Hash().Hex()
+		sender, err := decoded.Sender()
+		if err != nil {
+			resp.Message = fmt.Sprintf("recover sender fail: %v", err)
+			return resp, nil
+		}
+		resp.Sender = sender.Hex()
+		if tx.To() == nil {
+			resp.ContractAddress = crypto.CreateAddress(sender, tx.Nonce()).Hex()
+		}
+	}
+	if tx.To() != nil {
+		// 选择器与代币方法相同但参数无法解析的 calldata（选择器碰撞或非标准编码）不影响其余字段，token_call 留空
+		tokenCall, err := DecodeTokenCall(tx.Data())
+		if err != nil {
+			log.Warn("decode token call fail", "to", tx.To(), "err", err)
+		}
+		if tokenCall != nil {
+			resp.TokenCall = toWalletTokenCall(tx.To(), tokenCall)
+			resp.TokenCall.DisplayAmount = c.network.displayTokenAmount(*tx.To(), tx.Data())
+		}
+	}
+	var sender *common.Address
+	if resp.Sender != "" {
+		address := common.HexToAddress(resp.Sender)
+		sender = &address
+	}
+	resp.Intent = c.network.txIntent(tx, decoded.ChainID, sender)
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "decode transaction success"
+	return resp, nil
+}
 
-	eip1559Signer, signedTx, signAndHandledTx, txHash, err := CreateEip1559SignedTx(dFeeTx, inputSignatureByteList, dFeeTx.ChainID)
+func (c ChainAdaptor) SignSafeTransaction(ctx context.Context, req *wallet.SignSafeTransactionRequest) (*wallet.SignSafeTransactionResponse, error) {
+	resp := &wallet.SignSafeTransactionResponse{Code: wallet.ReturnCode_ERROR}
+
+	if len(req.PublicKeys) == 0 {
+		resp.Message = "public_keys is required"
+		return resp, nil
+	}
+	chainID, ok := new(big.Int).SetString(req.ChainId, 10)
+	if !ok || chainID.Sign() <= 0 {
+		resp.Message = fmt.Sprintf("invalid chain ID: %s", req.ChainId)
+		return resp, nil
+	}
+	if err := c.network.checkChainID(chainID); err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	if !common.IsHexAddress(req.SafeAddress) {
+		resp.Message = fmt.Sprintf("invalid safe address: %s", req.SafeAddress)
+		return resp, nil
+	}
+	safeTx, err := parseSafeTx(req.SafeTx)
 	if err != nil {
-		log.Error("create signed tx fail", "err", err)
-		resp.Message = "create signed tx fail"
+		resp.Message = err.Error()
 		return resp, nil
 	}
-	log.Info("sign transaction success",
-		"eip1559Signer", eip1559Signer,
-		"signedTx", signedTx,
-		"signAndHandledTx", signAndHandledTx,
-		"txHash", txHash,
+	safe := common.HexToAddress(req.SafeAddress)
+	typedDataHash, err := HashTypedData(BuildSafeTxTypedData(chainID, safe, safeTx))
+	if err != nil {
+		log.Error("hash safe tx fail", "safe", safe, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	digest, err := SafeSigningDigest(typedDataHash.Digest, req.SignatureType)
+	if err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	signatures := make([]SafeSignature, 0, len(req.PublicKeys))
+	for _, publicKey := range req.PublicKeys {
+		owner, err := publicKeyToAddress(publicKey)
+		if err != nil {
+			resp.Message = err.Error()
+			return resp, nil
+		}
+		sig, err := c.signDigest(publicKey, digest)
+		if err != nil {
+			log.Error("sign safe tx fail", "owner", owner, "err", err)
+			resp.Message = fmt.Sprintf("owner %s: %v", owner.Hex(), err)
+			return resp, nil
+		}
+		signatures = append(signatures, SafeSignature{Owner: owner, Signature: ToSafeSignature(sig, req.SignatureType)})
+	}
+	packed, err := PackSafeSignatures(signatures)
+	if err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	log.Info("sign safe transaction success",
+		"safe", safe,
+		"to", safeTx.To,
+		"operation", safeTx.Operation,
+		"nonce", safeTx.Nonce,
+		"owners", len(signatures),
+		"safeTxHash", typedDataHash.Digest,
 	)
 	resp.Code = wallet.ReturnCode_SUCCESS
-	resp.Message = "sign whole transaction success"
-	resp.SignedTx = signAndHandledTx
-	resp.TxHash = txHash
-	resp.TxMessageHash = rawTx
+	resp.Message = "sign safe transaction success"
+	resp.SafeTxHash = typedDataHash.Digest.Hex()
+	resp.DomainSeparator = typedDataHash.DomainSeparator.Hex()
+	resp.Signatures = hexutil.Encode(packed)
+	for _, signature := range signatures {
+		resp.OwnerSignatures = append(resp.OwnerSignatures, &wallet.SafeOwnerSignature{
+			Owner:     signature.Owner.Hex(),
+			Signature: hexutil.Encode(signature.Signature),
+		})
+	}
 	return resp, nil
 }
 
-func (c ChainAdaptor) buildDynamicFeeTx(base64Tx string) (*types.DynamicFeeTx, *Eip1559DynamicFeeTx, error) {
-	// 1. Decode base64 string
-	txReqJsonByte, err := base64.StdEncoding.DecodeString(base64Tx)
+func (c ChainAdaptor) SignUserOperation(ctx context.Context, req *wallet.SignUserOperationRequest) (*wallet.SignUserOperationResponse, error) {
+	resp := &wallet.SignUserOperationResponse{Code: wallet.ReturnCode_ERROR}
+
+	chainID, ok := new(big.Int).SetString(req.ChainId, 10)
+	if !ok || chainID.Sign() <= 0 {
+		resp.Message = fmt.Sprintf("invalid chain ID: %s", req.ChainId)
+		return resp, nil
+	}
+	if err := c.network.checkChainID(chainID); err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	entryPoint, err := defaultEntryPoint(req.EntryPointVersion)
 	if err != nil {
-		log.Error("decode string fail", "err", err)
-		return nil, nil, err
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	if req.EntryPoint != "" {
+		if !common.IsHexAddress(req.EntryPoint) {
+			resp.Message = fmt.Sprintf("invalid entry point: %s", req.EntryPoint)
+			return resp, nil
+		}
+		entryPoint = common.HexToAddress(req.EntryPoint)
 	}
+	userOp, err := parseUserOperation(req.EntryPointVersion, req.UserOp)
+	if err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	userOpHash, err := userOp.UserOpHash(req.EntryPointVersion, entryPoint, chainID)
+	if err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	digest, err := UserOpSigningDigest(userOpHash, req.SignatureType)
+	if err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	sig, err := c.signDigest(req.PublicKey, digest)
+	if err != nil {
+		log.Error("sign user operation fail", "sender", userOp.Sender, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	signature := hexutil.Encode(toWalletSignature(sig))
+	log.Info("sign user operation success",
+		"entryPointVersion", req.EntryPointVersion,
+		"entryPoint", entryPoint,
+		"sender", userOp.Sender,
+		"nonce", userOp.Nonce,
+		"userOpHash", userOpHash,
+	)
 
-	// 2. Unmarshal JSON to struct
-	var dynamicFeeTx Eip1559DynamicFeeTx
-	if err := json.Unmarshal(txReqJsonByte, &dynamicFeeTx); err != nil {
-		log.Error("parse json fail", "err", err)
-		return nil, nil, err
+	signedOp := proto.Clone(req.UserOp).(*wallet.UserOperation)
+	signedOp.Signature = signature
+	if req.EntryPointVersion == EntryPointV07 {
+		signedOp.InitCode = hexutil.Encode(userOp.InitCode)
+		signedOp.PaymasterAndData = hexutil.Encode(userOp.PaymasterAndData)
+		signedOp.AccountGasLimits = userOp.AccountGasLimits().Hex()
+		signedOp.GasFees = userOp.GasFees().Hex()
 	}
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign user operation success"
+	resp.UserOpHash = userOpHash.Hex()
+	resp.Signature = signature
+	resp.UserOp = signedOp
+	return resp, nil
+}
 
-	// 3. Convert string values to big.Int
-	chainID := new(big.Int)
-	maxPriorityFeePerGas := new(big.Int)
-	maxFeePerGas := new(big.Int)
-	amount := new(big.Int)
+func (c ChainAdaptor) SignSiweMessage(ctx context.Context, req *wallet.SignSiweMessageRequest) (*wallet.SignSiweMessageResponse, error) {
+	resp := &wallet.SignSiweMessageResponse{Code: wallet.ReturnCode_ERROR}
+	if req.Siwe == nil {
+		resp.Message = "siwe is required"
+		return resp, nil
+	}
 
-	log.Info("Dynamic fee tx",
-		"ChainId", dynamicFeeTx.ChainId,
-		"MaxPriorityFeePerGas", dynamicFeeTx.MaxPriorityFeePerGas,
-		"MaxFeePerGas", dynamicFeeTx.MaxFeePerGas,
-		"Amount", dynamicFeeTx.Amount,
+	// 消息中的地址必须是实际签名的地址，否则 dApp 恢复出的签名者与声明不一致
+	publicKey, signer, err := c.resolveSigner(req.PublicKey, req.Siwe.Address)
+	if err != nil {
+		log.Error("resolve siwe signer fail", "address", req.Siwe.Address, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	siwe, err := parseSiweMessage(req.Siwe, signer, time.Now())
+	if err != nil {
+		log.Error("parse siwe message fail", "domain", req.Siwe.Domain, "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	if err := c.network.checkChainID(siwe.ChainID); err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+
+	text := siwe.String()
+	digest := PersonalMessageHash([]byte(text))
+	sig, err := c.signDigest(publicKey, digest)
+	if err != nil {
+		log.Error("sign siwe message fail", "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	log.Info("sign siwe message success",
+		"domain", siwe.Domain,
+		"address", signer,
+		"chainId", siwe.ChainID,
+		"nonce", siwe.Nonce,
+		"digest", digest,
 	)
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign siwe message success"
+	resp.SiweMessage = text
+	resp.Digest = digest.Hex()
+	resp.Signature = hexutil.Encode(toWalletSignature(sig))
+	return resp, nil
+}
 
-	if _, ok := chainID.SetString(dynamicFeeTx.ChainId, 10); !ok {
-		return nil, nil, fmt.Errorf("invalid chain ID: %s", dynamicFeeTx.ChainId)
+func (c ChainAdaptor) SignFlashbotsBundle(ctx context.Context, req *wallet.SignFlashbotsBundleRequest) (*wallet.SignFlashbotsBundleResponse, error) {
+	resp := &wallet.SignFlashbotsBundleResponse{Code: wallet.ReturnCode_ERROR}
+
+	if err := checkBundleRequest(req); err != nil {
+		resp.Message = err.Error()
+		return resp, nil
 	}
-	if _, ok := maxPriorityFeePerGas.SetString(dynamicFeeTx.MaxPriorityFeePerGas, 10); !ok {
-		return nil, nil, fmt.Errorf("invalid max priority fee: %s", dynamicFeeTx.MaxPriorityFeePerGas)
+	if c.searcherKey() == "" {
+		resp.Message = errSearcherKeyNotConfigured.Error()
+		return resp, nil
 	}
-	if _, ok := maxFeePerGas.SetString(dynamicFeeTx.MaxFeePerGas, 10); !ok {
-		return nil, nil, fmt.Errorf("invalid max fee: %s", dynamicFeeTx.MaxFeePerGas)
+
+	// bundle 内交易全部通过检查后才签名，任何一笔失败整个 bundle 都不签、不记录 nonce
+	txWithSignList, err := c.signBundleTxs(req.Txs)
+	if err != nil {
+		log.Error("sign bundle txs fail", "err", err)
+		resp.Message = err.Error()
+		resp.RejectReason = rejectReason(err)
+		return resp, nil
 	}
-	if _, ok := amount.SetString(dynamicFeeTx.Amount, 10); !ok {
-		return nil, nil, fmt.Errorf("invalid amount: %s", dynamicFeeTx.Amount)
+	for _, signed := range txWithSignList {
+		signed.Code = wallet.ReturnCode_SUCCESS
+		signed.Message = "sign transaction success"
 	}
 
-	// 4. Handle addresses and data
-	toAddress := common.HexToAddress(dynamicFeeTx.ToAddress)
-	var finalToAddress common.Address
-	var finalAmount *big.Int
-	var buildData []byte
-	log.Info("contract address check",
-		"contractAddress", dynamicFeeTx.ContractAddress,
-		"isEthTransfer", isEthTransfer(&dynamicFeeTx),
+	body, err := buildSendBundleBody(req, txWithSignList)
+	if err != nil {
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	flashbotsSignature, searcher, err := c.signFlashbotsPayload(body)
+	if err != nil {
+		log.Error("sign flashbots payload fail", "err", err)
+		resp.Message = err.Error()
+		return resp, nil
+	}
+	txHashes := make([]string, len(txWithSignList))
+	for i, item := range txWithSignList {
+		txHashes[i] = item.TxHash
+	}
+	auditLog().Info("sign flashbots bundle",
+		"network", c.networkName(),
+		"searcher", searcher,
+		"blockNumber", req.BlockNumber,
+		"txHashes", txHashes,
+		"bodyHash", crypto.Keccak256Hash(body),
 	)
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign flashbots bundle success"
+	resp.TxWithSign = txWithSignList
+	resp.Body = string(body)
+	resp.FlashbotsSignature = flashbotsSignature
+	resp.SearcherAddress = searcher.Hex()
+	return resp, nil
+}
 
-	// 5. Handle contract interaction vs direct transfer
-	if isEthTransfer(&dynamicFeeTx) {
-		log.Info("native token transfer")
-		finalToAddress = toAddress
-		finalAmount = amount
-	} else {
-		log.Info("erc20 token transfer")
-		contractAddress := common.HexToAddress(dynamicFeeTx.ContractAddress)
-		buildData = BuildErc20Data(toAddress, amount)
-		finalToAddress = contractAddress
-		finalAmount = big.NewInt(0)
+func (c ChainAdaptor) BuildAndSignBatchTransaction(ctx context.Context, req *wallet.BuildAndSignBatchTransactionRequest) (*wallet.BuildAndSignBatchTransactionResponse, error) {
+	resp := &wallet.BuildAndSignBatchTransactionResponse{Code: wallet.ReturnCode_ERROR}
+	if len(req.TxMsg) == 0 {
+		resp.Message = "batch transaction list is empty"
+		return resp, nil
+	}
+	if len(req.TxMsg) > maxBatchSignNum {
+		resp.Message = fmt.Sprintf("batch size must be <= %d", maxBatchSignNum)
+		return resp, nil
 	}
 
-	// 6. Create dynamic fee transaction
-	dFeeTx := &types.DynamicFeeTx{
-		ChainID:   chainID,
-		Nonce:     dynamicFeeTx.Nonce,
-		GasTipCap: maxPriorityFeePerGas,
-		GasFeeCap: maxFeePerGas,
-		Gas:       dynamicFeeTx.GasLimit,
-		To:        &finalToAddress,
-		Value:     finalAmount,
-		Data:      buildData,
+	// 每笔交易独立构造并签名，结果按请求顺序返回；并发度由 batchConcurrency 限制
+	txWithSignList := make([]*wallet.TransactionWithSign, len(req.TxMsg))
+	sem := make(chan struct{}, c.batchConcurrency())
+	var wg sync.WaitGroup
+	for i, txMsg := range req.TxMsg {
+		wg.Add(1)
+		go func(i int, txMsg *wallet.TransactionMessage) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			txWithSignList[i] = c.signBatchItem(ctx, txMsg)
+		}(i, txMsg)
 	}
+	wg.Wait()
 
-	return dFeeTx, &dynamicFeeTx, nil
+	successNum := 0
+	for _, item := range txWithSignList {
+		if item.Code == wallet.ReturnCode_SUCCESS {
+			successNum++
+		}
+	}
+	log.Info("sign batch transaction finish", "total", len(txWithSignList), "success", successNum)
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = fmt.Sprintf("sign batch transaction finish, %d/%d success", successNum, len(txWithSignList))
+	resp.TxWithSign = txWithSignList
+	return resp, nil
 }
 
-func isEthTransfer(tx *Eip1559DynamicFeeTx) bool {
-	if tx.ContractAddress == "" || strings.ToLower(tx.ContractAddress) == "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee" {
-		return true
+func (c ChainAdaptor) signBatchItem(ctx context.Context, txMsg *wallet.TransactionMessage) (item *wallet.TransactionWithSign) {
+	item = &wallet.TransactionWithSign{Code: wallet.ReturnCode_ERROR}
+	// goroutine 里的 panic 不会被 Interceptor 的 recover 捕获，这里单独兜底，避免拖垮整个进程
+	defer func() {
+		if e := recover(); e != nil {
+			log.Error("sign batch item panic", "err", e)
+			item = &wallet.TransactionWithSign{Code: wallet.ReturnCode_ERROR, Message: fmt.Sprintf("sign transaction panic: %v", e)}
+		}
+	}()
+	if err := ctx.Err(); err != nil {
+		item.Message = err.Error()
+		return item
 	}
-	return false
+	signed, err := c.buildAndSignTx(txMsg.PublicKey, txMsg.TxBase64Body, txMsg.UnsignedTx, signOptions{
+		replacement:       txMsg.Replacement,
+		replacementReason: txMsg.ReplacementReason,
+		simulation:        txMsg.Simulation,
+	})
+	if err != nil {
+		log.Error("sign batch item fail", "publicKey", txMsg.PublicKey, "err", err)
+		item.Message = err.Error()
+		item.RejectReason = rejectReason(err)
+		item.Simulation = signed.GetSimulation()
+		return item
+	}
+	signed.Code = wallet.ReturnCode_SUCCESS
+	signed.Message = "sign transaction success"
+	return signed
+}
+
+func (c ChainAdaptor) batchConcurrency() int {
+	if c.conf != nil && c.conf.BatchSignConcurrency > 0 {
+		return c.conf.BatchSignConcurrency
+	}
+	return runtime.NumCPU()
+}
+
+func (c ChainAdaptor) networkName() string {
+	if c.network == nil {
+		return ChainName
+	}
+	return c.network.name
+}
+
+func (c ChainAdaptor) permitPolicy() config.PermitPolicy {
+	if c.conf == nil {
+		return config.PermitPolicy{}
+	}
+	return c.conf.PermitPolicy
+}
+
+func (c ChainAdaptor) BuildAndSignTransaction(ctx context.Context, req *wallet.BuildAndSignTransactionRequest) (*wallet.BuildAndSignTransactionResponse, error) {
+	resp := &wallet.BuildAndSignTransactionResponse{Code: wallet.ReturnCode_ERROR}
+
+	signed, err := c.buildAndSignTx(req.PublicKey, req.TxBase64Body, req.UnsignedTx, signOptions{
+		replacement:       req.Replacement,
+		replacementReason: req.ReplacementReason,
+		simulation:        req.Simulation,
+	})
+	if err != nil {
+		resp.Message = err.Error()
+		resp.RejectReason = rejectReason(err)
+		resp.Simulation = signed.GetSimulation()
+		return resp, nil
+	}
+	resp.Code = wallet.ReturnCode_SUCCESS
+	resp.Message = "sign whole transaction success"
+	resp.SignedTx = signed.SignedTx
+	resp.TxHash = signed.TxHash
+	resp.TxMessageHash = signed.TxMessageHash
+	resp.ContractAddress = signed.ContractAddress
+	resp.Simulation = signed.Simulation
+	resp.DisplayAmount = signed.DisplayAmount
+	resp.Intent = signed.Intent
+	return resp, nil
+}
+
+// buildAndSignTx 解析 base64 交易体（或未签名 envelope）、构造交易并用 publicKey 对应的私钥签名，单笔与批量共用
+func (c ChainAdaptor) buildAndSignTx(publicKey string, txBase64Body string, unsignedEnvelope string, opts signOptions) (*wallet.TransactionWithSign, error) {
+	prepared, err := c.prepareTx(publicKey, txBase64Body, unsignedEnvelope, opts)
+	if err != nil {
+		// 预执行拒绝时仍把预执行结果带回给调用方
+		if prepared != nil {
+			return &wallet.TransactionWithSign{Simulation: prepared.simulation}, err
+		}
+		return nil, err
+	}
+
+	// 4) 同一地址同一 nonce 只允许签一笔交易，检查与写入记录在同一把锁内完成
+	defer lockSenderNonce(prepared.unsigned.chainID, prepared.sender)()
+	if err := c.checkPreparedNonce(prepared); err != nil {
+		return nil, err
+	}
+	if err := c.signPreparedTx(prepared); err != nil {
+		return nil, err
+	}
+	if err := c.recordSignedNonces([]*preparedTx{prepared}); err != nil {
+		log.Error("record signed nonce fail", "sender", prepared.sender, "nonce", prepared.tx.Nonce(), "err", err)
+		return nil, fmt.Errorf("record signed nonce fail: %w", err)
+	}
+	return c.signedTxResult(prepared), nil
+}
+
+// preparedTx 是已通过解析、策略检查与预执行、尚未签名的交易；bundle 先准备好全部交易，再统一签名并记录 nonce
+type preparedTx struct {
+	unsigned   *unsignedTx
+	opts       signOptions
+	publicKey  string
+	sender     common.Address
+	tx         *types.Transaction
+	digest     common.Hash // 托管授权签名之前的 digest，用于 nonce 记录
+	simulation *wallet.SimulationResult
+	previous   *leveldb.SignedNonce // 该 nonce 上已有的签名记录，由 checkPreparedNonce 填充
+
+	// 以下字段由 signPreparedTx 填充
+	signingDigest common.Hash
+	signedTx      string
+	txHash        string
+}
+
+// prepareTx 完成签名前除 nonce 检查之外的全部步骤，不取私钥、不写任何记录；
+// 预执行拒绝时返回的 preparedTx 只带预执行结果
+func (c ChainAdaptor) prepareTx(publicKey string, txBase64Body string, unsignedEnvelope string, opts signOptions) (*preparedTx, error) {
+	// 1) 解析 & 构造 tx
+	unsigned, err := c.parseTxRequest(txBase64Body, unsignedEnvelope)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.network.checkTx(unsigned); err != nil {
+		log.Error("transaction rejected by policy", "network", c.networkName(), "reason", rejectReason(err), "err", err)
+		return nil, err
+	}
+	if err := c.checkAuthorizations(unsigned); err != nil {
+		log.Error("set code authorization rejected", "err", err)
+		return nil, err
+	}
+
+	// 2) 确定签名公钥：未传 public_key 时按 from_address 查地址索引；from_address 必须与公钥推导出的地址一致
+	publicKey, sender, err := c.resolveSigner(publicKey, unsigned.fromAddress)
+	if err != nil {
+		log.Error("resolve signer fail", "fromAddress", unsigned.fromAddress, "err", err)
+		return nil, err
+	}
+
+	// 3) 待签名hash (digest)：对 TxData 规范化编码 + keccak256，结果 32字节。
+	// set code 交易的托管授权此时尚未签名，这个 digest 只用于 nonce 记录；授权签名是确定性的，同一请求重签结果一致
+	prepared := &preparedTx{
+		unsigned:  unsigned,
+		opts:      opts,
+		publicKey: publicKey,
+		sender:    sender,
+		tx:        types.NewTx(unsigned.txData),
+		digest:    unsigned.digest(),
+	}
+
+	// 可选的本地预执行：revert 或转出代币超过声明时拒绝
+	if opts.simulation != nil {
+		chainConfig, err := c.network.simulationChainConfig(unsigned.chainID)
+		if err != nil {
+			log.Error("simulate transaction fail", "sender", sender, "err", err)
+			return nil, fmt.Errorf("simulate transaction fail: %w", err)
+		}
+		simulation, err := simulateTx(chainConfig, sender, prepared.tx, unsigned.authorities(), opts.simulation, time.Now())
+		if err != nil {
+			log.Error("simulate transaction fail", "sender", sender, "err", err)
+			return nil, fmt.Errorf("simulate transaction fail: %w", err)
+		}
+		prepared.simulation = simulation
+		if err := checkSimulation(sender, prepared.tx, opts.simulation, simulation); err != nil {
+			log.Error("transaction rejected by simulation", "sender", sender, "reason", rejectReason(err), "err", err)
+			return &preparedTx{simulation: simulation}, err
+		}
+		log.Info("simulate transaction success", "sender", sender, "gasUsed", simulation.GasUsed, "transfers", len(simulation.Transfers))
+	}
+	return prepared, nil
+}
+
+// checkPreparedNonce 执行 nonce 检查，调用方需持有 sender 的 nonce 锁直到记录写入
+func (c ChainAdaptor) checkPreparedNonce(p *preparedTx) error {
+	previous, err := c.checkSignedNonce(p.unsigned.chainID, p.sender, p.tx, p.digest, p.opts)
+	if err != nil {
+		log.Error("transaction rejected by nonce guard", "sender", p.sender, "nonce", p.tx.Nonce(), "reason", rejectReason(err), "err", err)
+		return err
+	}
+	p.previous = previous
+	return nil
+}
+
+// signPreparedTx 签名并组装交易，不写 nonce 记录
+func (c ChainAdaptor) signPreparedTx(p *preparedTx) error {
+	// 5) 所有检查通过后才签 EIP-7702 授权，授权签名写入交易后重新计算 digest，再取私钥签名
+	p.signingDigest = p.digest
+	if len(p.unsigned.authKeys) > 0 {
+		if err := c.signAuthorizations(p.unsigned); err != nil {
+			log.Error("sign set code authorization fail", "err", err)
+			return err
+		}
+		p.signingDigest = p.unsigned.digest()
+		p.tx = types.NewTx(p.unsigned.txData)
+	}
+	inputSignatureByteList, err := c.signDigest(p.publicKey, p.signingDigest)
+	if err != nil {
+		log.Error("sign transaction fail", "err", err)
+		return fmt.Errorf("sign transaction fail: %w", err)
+	}
+
+	// 6) 组装签名后的交易
+	p.signedTx, p.txHash, err = p.unsigned.assemble(inputSignatureByteList)
+	if err != nil {
+		log.Error("create signed tx fail", "err", err)
+		return fmt.Errorf("create signed tx fail: %w", err)
+	}
+	return nil
+}
+
+// signedTxResult 在 nonce 记录写入后生成返回结果，并记录审计日志
+func (c ChainAdaptor) signedTxResult(p *preparedTx) *wallet.TransactionWithSign {
+	tx, sender := p.tx, p.sender
+	displayAmount := c.network.displayAmount(tx)
+	intent := c.network.txIntent(tx, p.unsigned.chainID, &sender)
+	auditLog().Info("sign transaction intent",
+		"network", c.networkName(),
+		"action", intent.Action,
+		"sender", sender,
+		"nonce", tx.Nonce(),
+		"txHash", p.txHash,
+		"intent", intent.Summary,
+	)
+	log.Info("sign transaction success",
+		"network", c.networkName(),
+		"txType", p.unsigned.txType,
+		"sender", sender,
+		"amount", displayAmount,
+		"signAndHandledTx", p.signedTx,
+		"txHash", p.txHash,
+	)
+	txWithSign := &wallet.TransactionWithSign{
+		TxMessageHash: p.signingDigest.Hex(),
+		TxHash:        p.txHash,
+		SignedTx:      p.signedTx,
+		Simulation:    p.simulation,
+		DisplayAmount: displayAmount,
+		Intent:        intent,
+	}
+
+	// 7) 合约部署交易：合约地址由 sender 与 nonce 决定，可在广播前预先算出
+	if tx.To() == nil {
+		txWithSign.ContractAddress = crypto.CreateAddress(sender, tx.Nonce()).Hex()
+		log.Info("contract deployment address", "sender", sender, "nonce", tx.Nonce(), "contractAddress", txWithSign.ContractAddress)
+	}
+	return txWithSign
+}
+
+// parseTxRequest 按请求形式得到待签名交易：JSON 交易体走 buildUnsignedTx，未签名 envelope 直接解码，两者之后的校验完全一致
+func (c ChainAdaptor) parseTxRequest(txBase64Body string, unsignedEnvelope string) (*unsignedTx, error) {
+	if unsignedEnvelope != "" {
+		if txBase64Body != "" {
+			return nil, errors.New("tx_base64_body and unsigned_tx are mutually exclusive")
+		}
+		unsigned, err := parseUnsignedEnvelope(unsignedEnvelope)
+		if err != nil {
+			log.Error("decode unsigned tx fail", "err", err)
+			return nil, fmt.Errorf("decode unsigned tx fail: %w", err)
+		}
+		return unsigned, nil
+	}
+	txReqJsonByte, err := base64.StdEncoding.DecodeString(txBase64Body)
+	if err != nil {
+		log.Error("decode string fail", "err", err)
+		return nil, fmt.Errorf("decode tx body fail: %w", err)
+	}
+	unsigned, err := c.buildUnsignedTx(txReqJsonByte)
+	if err != nil {
+		log.Error("build transaction fail", "err", err)
+		return nil, fmt.Errorf("build transaction fail: %w", err)
+	}
+	return unsigned, nil
+}
+
+// parseUnsignedEnvelope 解码 ethers / foundry 输出的未签名交易；envelope 不含发送方，签名公钥只能由 public_key 指定
+func parseUnsignedEnvelope(raw string) (*unsignedTx, error) {
+	rawBytes, err := DecodeRawTxBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := DecodeRawTransaction(rawBytes)
+	if err != nil {
+		return nil, err
+	}
+	if decoded.Signed {
+		return nil, errors.New("transaction is already signed")
+	}
+	tx := decoded.Tx
+	// 与 JSON 交易体一致，legacy 交易必须带 EIP-155 chainId，防止跨链重放
+	if decoded.ChainID == nil || decoded.ChainID.Sign() <= 0 {
+		return nil, errors.New("unsigned transaction must carry a chain ID")
+	}
+	var txData types.TxData
+	switch tx.Type() {
+	case types.LegacyTxType:
+		txData = &types.LegacyTx{Nonce: tx.Nonce(), GasPrice: tx.GasPrice(), Gas: tx.Gas(), To: tx.To(), Value: tx.Value(), Data: tx.Data()}
+	case types.AccessListTxType:
+		txData = &types.AccessListTx{ChainID: decoded.ChainID, Nonce: tx.Nonce(), GasPrice: tx.GasPrice(), Gas: tx.Gas(), To: tx.To(), Value: tx.Value(), Data: tx.Data(), AccessList: tx.AccessList()}
+	case types.DynamicFeeTxType:
+		txData = &types.DynamicFeeTx{ChainID: decoded.ChainID, Nonce: tx.Nonce(), GasTipCap: tx.GasTipCap(), GasFeeCap: tx.GasFeeCap(), Gas: tx.Gas(), To: tx.To(), Value: tx.Value(), Data: tx.Data(), AccessList: tx.AccessList()}
+	case types.SetCodeTxType:
+		if tx.To() == nil {
+			return nil, errors.New("set code transaction requires a to address")
+		}
+		txData = &types.SetCodeTx{
+			ChainID:    uint256.MustFromBig(decoded.ChainID),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  uint256.MustFromBig(tx.GasTipCap()),
+			GasFeeCap:  uint256.MustFromBig(tx.GasFeeCap()),
+			Gas:        tx.Gas(),
+			To:         *tx.To(),
+			Value:      uint256.MustFromBig(tx.Value()),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+			AuthList:   tx.SetCodeAuthorizations(),
+		}
+	case types.BlobTxType:
+		// 未签名 envelope 不带 blob sidecar，签出来的交易无法广播；blob 交易请用 JSON 交易体由服务端生成 sidecar
+		return nil, errors.New("blob transaction envelope has no sidecar, use tx_base64_body instead")
+	default:
+		return nil, fmt.Errorf("unsupported tx type: %d", tx.Type())
+	}
+	return &unsignedTx{txType: TxTypeName(tx.Type()), chainID: decoded.ChainID, txData: txData}, nil
+}
+
+// unsignedTx 是按 tx_type 构造好、等待签名的交易
+type unsignedTx struct {
+	txType            string
+	chainID           *big.Int
+	txData            types.TxData
+	fromAddress       string   // 请求中声明的发送方地址，可为空
+	allowUnknownToken bool     // 请求中的 allow_unknown_token；unsigned_tx 信封没有该字段，始终按注册表检查
+	authKeys          []string // set code 交易中待托管私钥签名的授权对应的公钥，按 AuthList 下标；unsigned_tx 信封中的授权已带签名，为空
+}
+
+func (c ChainAdaptor) buildUnsignedTx(txReqJsonByte []byte) (*unsignedTx, error) {
+	var header txTypeHeader
+	if err := json.Unmarshal(txReqJsonByte, &header); err != nil {
+		log.Error("parse json fail", "err", err)
+		return nil, err
+	}
+	switch header.TxType {
+	case TxTypeLegacy:
+		legacyTx, chainID, legacyReq, err := c.buildLegacyTx(txReqJsonByte)
+		if err != nil {
+			return nil, err
+		}
+		return &unsignedTx{txType: TxTypeLegacy, chainID: chainID, txData: legacyTx, fromAddress: legacyReq.FromAddress, allowUnknownToken: legacyReq.AllowUnknownToken}, nil
+	case TxTypeAccessList:
+		accessListTx, accessListReq, err := c.buildAccessListTx(txReqJsonByte)
+		if err != nil {
+			return nil, err
+		}
+		return &unsignedTx{txType: TxTypeAccessList, chainID: accessListTx.ChainID, txData: accessListTx, fromAddress: accessListReq.FromAddress, allowUnknownToken: accessListReq.AllowUnknownToken}, nil
+	case TxTypeBlob:
+		blobTx, blobReq, err := c.buildBlobTx(txReqJsonByte)
+		if err != nil {
+			return nil, err
+		}
+		return &unsignedTx{txType: TxTypeBlob, chainID: blobTx.ChainID.ToBig(), txData: blobTx, fromAddress: blobReq.FromAddress, allowUnknownToken: blobReq.AllowUnknownToken}, nil
+	case TxTypeSetCode:
+		setCodeTx, setCodeReq, authKeys, err := c.buildSetCodeTx(txReqJsonByte)
+		if err != nil {
+			return nil, err
+		}
+		return &unsignedTx{txType: TxTypeSetCode, chainID: setCodeTx.ChainID.ToBig(), txData: setCodeTx, fromAddress: setCodeReq.FromAddress, allowUnknownToken: setCodeReq.AllowUnknownToken, authKeys: authKeys}, nil
+	case TxTypeDynamicFee, "":
+		dFeeTx, dFeeReq, err := c.buildDynamicFeeTx(txReqJsonByte)
+		if err != nil {
+			return nil, err
+		}
+		return &unsignedTx{txType: TxTypeDynamicFee, chainID: dFeeTx.ChainID, txData: dFeeTx, fromAddress: dFeeReq.FromAddress, allowUnknownToken: dFeeReq.AllowUnknownToken}, nil
+	default:
+		return nil, fmt.Errorf("unsupported tx type: %s", header.TxType)
+	}
+}
+
+func (u *unsignedTx) digest() common.Hash {
+	switch txData := u.txData.(type) {
+	case *types.LegacyTx:
+		return CreateLegacyUnSignTx(txData, u.chainID)
+	case *types.AccessListTx:
+		return CreateAccessListUnSignTx(txData, u.chainID)
+	case *types.DynamicFeeTx:
+		return CreateEip1559UnSignTx(txData, u.chainID)
+	case *types.BlobTx:
+		return CreateBlobUnSignTx(txData, u.chainID)
+	case *types.SetCodeTx:
+		return CreateSetCodeUnSignTx(txData, u.chainID)
+	default:
+		return types.LatestSignerForChainID(u.chainID).Hash(types.NewTx(u.txData))
+	}
+}
+
+func (u *unsignedTx) assemble(sig []byte) (rawHex string, txHash string, err error) {
+	switch txData := u.txData.(type) {
+	case *types.LegacyTx:
+		return CreateLegacySignedTx(txData, sig, u.chainID)
+	case *types.AccessListTx:
+		return CreateAccessListSignedTx(txData, sig, u.chainID)
+	case *types.DynamicFeeTx:
+		_, _, rawHex, txHash, err = CreateEip1559SignedTx(txData, sig, u.chainID)
+		return rawHex, txHash, err
+	case *types.BlobTx:
+		return CreateBlobSignedTx(txData, sig, u.chainID)
+	case *types.SetCodeTx:
+		return CreateSetCodeSignedTx(txData, sig, u.chainID)
+	default:
+		return "", "", fmt.Errorf("unsupported tx data: %T", u.txData)
+	}
+}
+
+func (c ChainAdaptor) buildDynamicFeeTx(txReqJsonByte []byte) (*types.DynamicFeeTx, *Eip1559DynamicFeeTx, error) {
+	// 1. Unmarshal JSON to struct
+	var dynamicFeeTx Eip1559DynamicFeeTx
+	if err := json.Unmarshal(txReqJsonByte, &dynamicFeeTx); err != nil {
+		log.Error("parse json fail", "err", err)
+		return nil, nil, err
+	}
+
+	// 2. Convert string values to big.Int
+	chainID := new(big.Int)
+	maxPriorityFeePerGas := new(big.Int)
+	maxFeePerGas := new(big.Int)
+
+	log.Info("Dynamic fee tx",
+		"ChainId", dynamicFeeTx.ChainId,
+		"MaxPriorityFeePerGas", dynamicFeeTx.MaxPriorityFeePerGas,
+		"MaxFeePerGas", dynamicFeeTx.MaxFeePerGas,
+		"Amount", dynamicFeeTx.Amount,
+	)
+
+	if _, ok := chainID.SetString(dynamicFeeTx.ChainId, 10); !ok {
+		return nil, nil, fmt.Errorf("invalid chain ID: %s", dynamicFeeTx.ChainId)
+	}
+	if _, ok := maxPriorityFeePerGas.SetString(dynamicFeeTx.MaxPriorityFeePerGas, 10); !ok {
+		return nil, nil, fmt.Errorf("invalid max priority fee: %s", dynamicFeeTx.MaxPriorityFeePerGas)
+	}
+	if _, ok := maxFeePerGas.SetString(dynamicFeeTx.MaxFeePerGas, 10); !ok {
+		return nil, nil, fmt.Errorf("invalid max fee: %s", dynamicFeeTx.MaxFeePerGas)
+	}
+
+	// 3. Handle contract interaction vs direct transfer
+	finalToAddress, finalAmount, buildData, err := c.buildTxPayload(&dynamicFeeTx.TxPayload)
+	if err != nil {
+		return nil, nil, err
+	}
+	accessList, err := BuildAccessList(dynamicFeeTx.AccessList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 4. Create dynamic fee transaction
+	dFeeTx := &types.DynamicFeeTx{
+		ChainID:    chainID,
+		Nonce:      dynamicFeeTx.Nonce,
+		GasTipCap:  maxPriorityFeePerGas,
+		GasFeeCap:  maxFeePerGas,
+		Gas:        dynamicFeeTx.GasLimit,
+		To:         finalToAddress,
+		Value:      finalAmount,
+		Data:       buildData,
+		AccessList: accessList,
+	}
+
+	return dFeeTx, &dynamicFeeTx, nil
+}
+
+// buildLegacyTx 额外返回 chainId：LegacyTx 本身没有这个字段，但 EIP-155 签名需要它
+func (c ChainAdaptor) buildLegacyTx(txReqJsonByte []byte) (*types.LegacyTx, *big.Int, *LegacyFeeTx, error) {
+	var legacyFeeTx LegacyFeeTx
+	if err := json.Unmarshal(txReqJsonByte, &legacyFeeTx); err != nil {
+		log.Error("parse json fail", "err", err)
+		return nil, nil, nil, err
+	}
+
+	log.Info("Legacy fee tx",
+		"ChainId", legacyFeeTx.ChainId,
+		"GasPrice", legacyFeeTx.GasPrice,
+		"Amount", legacyFeeTx.Amount,
+	)
+
+	// EIP-155：chainId 参与签名，防止交易在其他链上被重放
+	chainID := new(big.Int)
+	if _, ok := chainID.SetString(legacyFeeTx.ChainId, 10); !ok || chainID.Sign() <= 0 {
+		return nil, nil, nil, fmt.Errorf("invalid chain ID: %s", legacyFeeTx.ChainId)
+	}
+
+	finalToAddress, finalAmount, buildData, err := c.buildTxPayload(&legacyFeeTx.TxPayload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	legacyTx := &types.LegacyTx{
+		Nonce:    legacyFeeTx.Nonce,
+		GasPrice: new(big.Int).SetUint64(legacyFeeTx.GasPrice),
+		Gas:      legacyFeeTx.GasLimit,
+		To:       finalToAddress,
+		Value:    finalAmount,
+		Data:     buildData,
+	}
+	return legacyTx, chainID, &legacyFeeTx, nil
+}
+
+func (c ChainAdaptor) buildAccessListTx(txReqJsonByte []byte) (*types.AccessListTx, *Eip2930AccessListTx, error) {
+	var accessListTx Eip2930AccessListTx
+	if err := json.Unmarshal(txReqJsonByte, &accessListTx); err != nil {
+		log.Error("parse json fail", "err", err)
+		return nil, nil, err
+	}
+
+	log.Info("Access list tx",
+		"ChainId", accessListTx.ChainId,
+		"GasPrice", accessListTx.GasPrice,
+		"Amount", accessListTx.Amount,
+		"AccessListLen", len(accessListTx.AccessList),
+	)
+
+	chainID := new(big.Int)
+	gasPrice := new(big.Int)
+	if _, ok := chainID.SetString(accessListTx.ChainId, 10); !ok || chainID.Sign() <= 0 {
+		return nil, nil, fmt.Errorf("invalid chain ID: %s", accessListTx.ChainId)
+	}
+	if _, ok := gasPrice.SetString(accessListTx.GasPrice, 10); !ok || gasPrice.Sign() < 0 {
+		return nil, nil, fmt.Errorf("invalid gas price: %s", accessListTx.GasPrice)
+	}
+
+	finalToAddress, finalAmount, buildData, err := c.buildTxPayload(&accessListTx.TxPayload)
+	if err != nil {
+		return nil, nil, err
+	}
+	accessList, err := BuildAccessList(accessListTx.AccessList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &types.AccessListTx{
+		ChainID:    chainID,
+		Nonce:      accessListTx.Nonce,
+		GasPrice:   gasPrice,
+		Gas:        accessListTx.GasLimit,
+		To:         finalToAddress,
+		Value:      finalAmount,
+		Data:       buildData,
+		AccessList: accessList,
+	}, &accessListTx, nil
+}
+
+func (c ChainAdaptor) buildBlobTx(txReqJsonByte []byte) (*types.BlobTx, *Eip4844BlobTx, error) {
+	var blobTx Eip4844BlobTx
+	if err := json.Unmarshal(txReqJsonByte, &blobTx); err != nil {
+		log.Error("parse json fail", "err", err)
+		return nil, nil, err
+	}
+
+	log.Info("Blob tx",
+		"ChainId", blobTx.ChainId,
+		"MaxPriorityFeePerGas", blobTx.MaxPriorityFeePerGas,
+		"MaxFeePerGas", blobTx.MaxFeePerGas,
+		"MaxFeePerBlobGas", blobTx.MaxFeePerBlobGas,
+		"BlobNum", len(blobTx.Blobs),
+	)
+
+	chainID, err := parseUint256("chain ID", blobTx.ChainId)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxPriorityFeePerGas, err := parseUint256("max priority fee", blobTx.MaxPriorityFeePerGas)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxFeePerGas, err := parseUint256("max fee", blobTx.MaxFeePerGas)
+	if err != nil {
+		return nil, nil, err
+	}
+	maxFeePerBlobGas, err := parseUint256("max fee per blob gas", blobTx.MaxFeePerBlobGas)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// blob 交易不能用于部署合约，to 必须存在
+	finalToAddress, finalAmount, buildData, err := c.buildTxPayload(&blobTx.TxPayload)
+	if err != nil {
+		return nil, nil, err
+	}
+	if finalToAddress == nil {
+		return nil, nil, errors.New("blob transaction cannot deploy a contract")
+	}
+	value, overflow := uint256.FromBig(finalAmount)
+	if overflow {
+		return nil, nil, fmt.Errorf("amount overflows uint256: %s", finalAmount)
+	}
+	accessList, err := BuildAccessList(blobTx.AccessList)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blobs := make([][]byte, len(blobTx.Blobs))
+	for i, blobHex := range blobTx.Blobs {
+		if blobs[i], err = hexutil.Decode(blobHex); err != nil {
+			return nil, nil, fmt.Errorf("invalid blob %d: %w", i, err)
+		}
+	}
+	sidecar, err := BuildBlobSidecar(blobs, blobTx.SidecarVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &types.BlobTx{
+		ChainID:    chainID,
+		Nonce:      blobTx.Nonce,
+		GasTipCap:  maxPriorityFeePerGas,
+		GasFeeCap:  maxFeePerGas,
+		Gas:        blobTx.GasLimit,
+		To:         *finalToAddress,
+		Value:      value,
+		Data:       buildData,
+		AccessList: accessList,
+		BlobFeeCap: maxFeePerBlobGas,
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	}, &blobTx, nil
+}
+
+func (c ChainAdaptor) buildSetCodeTx(txReqJsonByte []byte) (*types.SetCodeTx, *Eip7702SetCodeTx, []string, error) {
+	var setCodeTx Eip7702SetCodeTx
+	if err := json.Unmarshal(txReqJsonByte, &setCodeTx); err != nil {
+		log.Error("parse json fail", "err", err)
+		return nil, nil, nil, err
+	}
+
+	log.Info("Set code tx",
+		"ChainId", setCodeTx.ChainId,
+		"MaxPriorityFeePerGas", setCodeTx.MaxPriorityFeePerGas,
+		"MaxFeePerGas", setCodeTx.MaxFeePerGas,
+		"AuthorizationNum", len(setCodeTx.Authorizations),
+	)
+
+	chainID, err := parseUint256("chain ID", setCodeTx.ChainId)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	maxPriorityFeePerGas, err := parseUint256("max priority fee", setCodeTx.MaxPriorityFeePerGas)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	maxFeePerGas, err := parseUint256("max fee", setCodeTx.MaxFeePerGas)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// set code 交易同样不能用于部署合约
+	finalToAddress, finalAmount, buildData, err := c.buildTxPayload(&setCodeTx.TxPayload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if finalToAddress == nil {
+		return nil, nil, nil, errors.New("set code transaction cannot deploy a contract")
+	}
+	value, overflow := uint256.FromBig(finalAmount)
+	if overflow {
+		return nil, nil, nil, fmt.Errorf("amount overflows uint256: %s", finalAmount)
+	}
+	accessList, err := BuildAccessList(setCodeTx.AccessList)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if len(setCodeTx.Authorizations) == 0 {
+		return nil, nil, nil, errors.New("set code transaction requires at least one authorization")
+	}
+	// 这里只解析授权元组，签名推迟到网络策略、sender 校验、预执行与 nonce 检查都通过之后（signAuthorizations），
+	// 被拒绝的请求不会留下可被单独使用的委托签名
+	authList := make([]types.SetCodeAuthorization, 0, len(setCodeTx.Authorizations))
+	authKeys := make([]string, 0, len(setCodeTx.Authorizations))
+	for i := range setCodeTx.Authorizations {
+		auth, err := parseAuthorization(&setCodeTx.Authorizations[i])
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("authorization %d: %w", i, err)
+		}
+		authList = append(authList, auth)
+		authKeys = append(authKeys, setCodeTx.Authorizations[i].PublicKey)
+	}
+
+	return &types.SetCodeTx{
+		ChainID:    chainID,
+		Nonce:      setCodeTx.Nonce,
+		GasTipCap:  maxPriorityFeePerGas,
+		GasFeeCap:  maxFeePerGas,
+		Gas:        setCodeTx.GasLimit,
+		To:         *finalToAddress,
+		Value:      value,
+		Data:       buildData,
+		AccessList: accessList,
+		AuthList:   authList,
+	}, &setCodeTx, authKeys, nil
+}
+
+// parseAuthorization 解析待签名的授权元组，公钥必须是 secp256k1 公钥
+func parseAuthorization(authorization *Eip7702Authorization) (types.SetCodeAuthorization, error) {
+	chainID, err := parseUint256("authorization chain ID", authorization.ChainId)
+	if err != nil {
+		return types.SetCodeAuthorization{}, err
+	}
+	if !common.IsHexAddress(authorization.Address) {
+		return types.SetCodeAuthorization{}, fmt.Errorf("invalid delegate address: %s", authorization.Address)
+	}
+	if _, err := parsePublicKey(authorization.PublicKey); err != nil {
+		return types.SetCodeAuthorization{}, err
+	}
+	return types.SetCodeAuthorization{
+		ChainID: *chainID,
+		Address: common.HexToAddress(authorization.Address),
+		Nonce:   authorization.Nonce,
+	}, nil
+}
+
+// checkAuthorizations 在签任何授权之前校验 EIP-7702 授权：chain_id 必须与交易相同，
+// 为 0 的授权在所有 EVM 链上都能被重放，只有开启 allow_any_chain_authorization 时才签；
+// 委托的合约必须在 authorization_delegates 中，零地址即清除委托，总是允许
+func (c ChainAdaptor) checkAuthorizations(unsigned *unsignedTx) error {
+	setCodeTx, ok := unsigned.txData.(*types.SetCodeTx)
+	if !ok {
+		return nil
+	}
+	for i, auth := range setCodeTx.AuthList {
+		switch {
+		case auth.ChainID.IsZero():
+			if c.conf == nil || !c.conf.AllowAnyChainAuthorization {
+				return fmt.Errorf("authorization %d: chain ID 0 is valid on every chain, set allow_any_chain_authorization to sign it", i)
+			}
+		case auth.ChainID.ToBig().Cmp(unsigned.chainID) != 0:
+			return fmt.Errorf("authorization %d: chain ID %s does not match transaction chain ID %s", i, auth.ChainID.ToBig(), unsigned.chainID)
+		}
+		if auth.Address != (common.Address{}) && !c.authorizationDelegateAllowed(auth.Address) {
+			return fmt.Errorf("authorization %d: delegate %s is not in authorization_delegates", i, auth.Address.Hex())
+		}
+	}
+	return nil
+}
+
+func (c ChainAdaptor) authorizationDelegateAllowed(delegate common.Address) bool {
+	if c.conf == nil {
+		return false
+	}
+	for _, allowed := range c.conf.AuthorizationDelegates {
+		if common.HexToAddress(allowed) == delegate {
+			return true
+		}
+	}
+	return false
+}
+
+// signAuthorizations 用授权账户的托管私钥对 keccak256(0x05 || rlp([chain_id, address, nonce])) 签名，写回 AuthList；
+// 交易的 digest 覆盖授权签名，所以必须在计算最终 digest 之前调用
+func (c ChainAdaptor) signAuthorizations(unsigned *unsignedTx) error {
+	setCodeTx, ok := unsigned.txData.(*types.SetCodeTx)
+	if !ok {
+		return nil
+	}
+	for i, publicKey := range unsigned.authKeys {
+		auth := &setCodeTx.AuthList[i]
+		sig, err := c.signDigest(publicKey, auth.SigHash())
+		if err != nil {
+			return fmt.Errorf("authorization %d: %w", i, err)
+		}
+		auth.R.SetBytes(sig[:32])
+		auth.S.SetBytes(sig[32:64])
+		auth.V = sig[crypto.RecoveryIDOffset]
+		log.Info("sign set code authorization", "delegate", auth.Address, "chainId", auth.ChainID.ToBig(), "nonce", auth.Nonce)
+	}
+	return nil
+}
+
+// authorities 返回每个授权的授权账户：待签名的由公钥推导，unsigned_tx 信封中已带签名的从签名恢复，无法恢复时为零地址
+func (u *unsignedTx) authorities() []common.Address {
+	setCodeTx, ok := u.txData.(*types.SetCodeTx)
+	if !ok {
+		return nil
+	}
+	authorities := make([]common.Address, len(setCodeTx.AuthList))
+	for i, auth := range setCodeTx.AuthList {
+		if i < len(u.authKeys) {
+			authorities[i], _ = publicKeyToAddress(u.authKeys[i])
+			continue
+		}
+		authorities[i], _ = auth.Authority()
+	}
+	return authorities
+}
+
+func parseUint256(name string, value string) (*uint256.Int, error) {
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	u, overflow := uint256.FromBig(n)
+	if overflow {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return u, nil
+}
+
+// buildTxPayload 根据 token_type / contract_address 区分原生币、ERC20 与 NFT 转账、合约调用与合约部署，返回交易的 to、value 与 data；部署合约时 to 为 nil。
+// amount 可以带 symbol（如 "12.5 USDC"），按网络的代币注册表换算成最小单位
+func (c ChainAdaptor) buildTxPayload(payload *TxPayload) (*common.Address, *big.Int, []byte, error) {
+	payload, err := c.network.resolveTokenAmount(payload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if isContractDeployment(payload) {
+		return buildContractDeployment(payload)
+	}
+	if isContractCall(payload) {
+		return buildContractCall(payload)
+	}
+	if !common.IsHexAddress(payload.ToAddress) {
+		return nil, nil, nil, fmt.Errorf("invalid to address: %s", payload.ToAddress)
+	}
+	toAddress := common.HexToAddress(payload.ToAddress)
+	tokenType := payload.TokenType
+	if tokenType == "" {
+		tokenType = TokenTypeErc20
+		if isEthTransfer(payload) {
+			tokenType = TokenTypeNative
+		}
+	}
+	log.Info("contract address check",
+		"contractAddress", payload.ContractAddress,
+		"tokenType", tokenType,
+	)
+	if tokenType != TokenTypeNative && !common.IsHexAddress(payload.ContractAddress) {
+		return nil, nil, nil, fmt.Errorf("invalid contract address for %s transfer: %s", tokenType, payload.ContractAddress)
+	}
+	contractAddress := common.HexToAddress(payload.ContractAddress)
+
+	switch tokenType {
+	case TokenTypeNative:
+		log.Info("native token transfer")
+		amount, err := parseAmount(payload.Amount)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &toAddress, amount, nil, nil
+	case TokenTypeErc20:
+		log.Info("erc20 token transfer")
+		amount, err := parseAmount(payload.Amount)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &contractAddress, big.NewInt(0), BuildErc20Data(toAddress, amount), nil
+	case TokenTypeErc721:
+		log.Info("erc721 token transfer", "tokenId", payload.TokenId)
+		fromAddress, tokenId, err := parseNftTransfer(payload)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		data, err := BuildErc721Data(fromAddress, toAddress, tokenId)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &contractAddress, big.NewInt(0), data, nil
+	case TokenTypeErc1155:
+		log.Info("erc1155 token transfer", "tokenId", payload.TokenId, "amount", payload.Amount)
+		fromAddress, tokenId, err := parseNftTransfer(payload)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		amount, err := parseAmount(payload.Amount)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		var tokenData []byte
+		if payload.TokenData != "" {
+			if tokenData, err = hexutil.Decode(payload.TokenData); err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid token data: %w", err)
+			}
+		}
+		data, err := BuildErc1155Data(fromAddress, toAddress, tokenId, amount, tokenData)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return &contractAddress, big.NewInt(0), data, nil
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported token type: %s", payload.TokenType)
+	}
+}
+
+// buildContractCall 构造通用合约调用：calldata 取自 data，或由 method + args 做 ABI 编码
+func buildContractCall(payload *TxPayload) (*common.Address, *big.Int, []byte, error) {
+	if payload.TokenType != "" {
+		return nil, nil, nil, errors.New("token_type cannot be combined with data or method")
+	}
+	if payload.Data != "" && payload.Method != "" {
+		return nil, nil, nil, errors.New("data and method are mutually exclusive")
+	}
+	if !common.IsHexAddress(payload.ContractAddress) {
+		return nil, nil, nil, fmt.Errorf("invalid contract address for contract call: %s", payload.ContractAddress)
+	}
+	value := big.NewInt(0)
+	if payload.Amount != "" {
+		amount, err := parseAmount(payload.Amount)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		value = amount
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if payload.Data != "" {
+		if data, err = hexutil.Decode(payload.Data); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid calldata: %w", err)
+		}
+	} else if data, err = BuildContractCallData(payload.Method, payload.Args); err != nil {
+		return nil, nil, nil, err
+	}
+	log.Info("contract call",
+		"contractAddress", payload.ContractAddress,
+		"method", payload.Method,
+		"value", value,
+		"dataLen", len(data),
+	)
+	contractAddress := common.HexToAddress(payload.ContractAddress)
+	return &contractAddress, value, data, nil
+}
+
+// buildContractDeployment 构造合约创建交易：to 为空，data = 合约 init bytecode || ABI 编码后的构造函数参数
+func buildContractDeployment(payload *TxPayload) (*common.Address, *big.Int, []byte, error) {
+	if payload.ToAddress != "" || payload.ContractAddress != "" || payload.TokenType != "" || isContractCall(payload) {
+		return nil, nil, nil, errors.New("contract deployment only accepts bytecode, constructor_args and amount")
+	}
+	bytecode, err := hexutil.Decode(payload.Bytecode)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid bytecode: %w", err)
+	}
+	if len(bytecode) == 0 {
+		return nil, nil, nil, errors.New("bytecode is empty")
+	}
+	var constructorArgs []byte
+	if payload.ConstructorArgs != "" {
+		if constructorArgs, err = hexutil.Decode(payload.ConstructorArgs); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid constructor args: %w", err)
+		}
+	}
+	value := big.NewInt(0)
+	if payload.Amount != "" {
+		if value, err = parseAmount(payload.Amount); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	log.Info("contract deployment", "bytecodeLen", len(bytecode), "constructorArgsLen", len(constructorArgs), "value", value)
+
+	data := make([]byte, 0, len(bytecode)+len(constructorArgs))
+	data = append(data, bytecode...)
+	data = append(data, constructorArgs...)
+	return nil, value, data, nil
+}
+
+func isContractDeployment(payload *TxPayload) bool {
+	return payload.Bytecode != ""
+}
+
+func isContractCall(payload *TxPayload) bool {
+	return payload.Data != "" || payload.Method != ""
+}
+
+func parseAmount(amountStr string) (*big.Int, error) {
+	amount := new(big.Int)
+	if _, ok := amount.SetString(amountStr, 10); !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount: %s", amountStr)
+	}
+	return amount, nil
+}
+
+// parseNftTransfer 解析 NFT 转账的 from 与 tokenId；safeTransferFrom 的 from 必须是 NFT 持有者
+func parseNftTransfer(payload *TxPayload) (common.Address, *big.Int, error) {
+	if !common.IsHexAddress(payload.FromAddress) {
+		return common.Address{}, nil, fmt.Errorf("invalid from address: %s", payload.FromAddress)
+	}
+	tokenId := new(big.Int)
+	if _, ok := tokenId.SetString(payload.TokenId, 10); !ok {
+		return common.Address{}, nil, fmt.Errorf("invalid token id: %s", payload.TokenId)
+	}
+	return common.HexToAddress(payload.FromAddress), tokenId, nil
+}
+
+func isEthTransfer(payload *TxPayload) bool {
+	if payload.ContractAddress == "" || strings.ToLower(payload.ContractAddress) == "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee" {
+		return true
+	}
+	return false
+}
+
+func toWalletTokenCall(contract *common.Address, call *TokenCall) *wallet.TokenCall {
+	tokenCall := &wallet.TokenCall{
+		Standard: call.Standard,
+		Method:   call.Method,
+		Contract: contract.Hex(),
+		To:       call.To.Hex(),
+		Approved: call.Approved,
+	}
+	if call.From != nil {
+		tokenCall.From = call.From.Hex()
+	}
+	if call.Amount != nil {
+		tokenCall.Amount = call.Amount.String()
+	}
+	if call.TokenId != nil {
+		tokenCall.TokenId = call.TokenId.String()
+	}
+	for _, id := range call.Ids {
+		tokenCall.Ids = append(tokenCall.Ids, id.String())
+	}
+	for _, amount := range call.Amounts {
+		tokenCall.Amounts = append(tokenCall.Amounts, amount.String())
+	}
+	if len(call.Data) > 0 {
+		tokenCall.Data = hexutil.Encode(call.Data)
+	}
+	return tokenCall
+}
+
+// resolveSigner 返回签名用的公钥及其地址：publicKey 为空时按 fromAddress 查地址索引得到公钥，
+// 否则非空的 fromAddress 必须与公钥推导出的地址一致
+func (c ChainAdaptor) resolveSigner(publicKey string, fromAddress string) (string, common.Address, error) {
+	if fromAddress != "" && !common.IsHexAddress(fromAddress) {
+		return "", common.Address{}, fmt.Errorf("invalid from address: %s", fromAddress)
+	}
+	if publicKey == "" {
+		if fromAddress == "" {
+			return "", common.Address{}, errors.New("public key or from address is required")
+		}
+		indexed, ok := c.db.GetPubKeyByAddress(common.HexToAddress(fromAddress).Hex())
+		if !ok {
+			return "", common.Address{}, fmt.Errorf("%w: %s", errAddressNotFound, fromAddress)
+		}
+		publicKey = indexed
+	}
+	sender, err := publicKeyToAddress(publicKey)
+	if err != nil {
+		return "", common.Address{}, err
+	}
+	if fromAddress != "" && common.HexToAddress(fromAddress) != sender {
+		return "", common.Address{}, fmt.Errorf("%w: from address %s, public key address %s", errFromAddressMismatch, fromAddress, sender.Hex())
+	}
+	return publicKey, sender, nil
+}
+
+// signDigest 用 publicKey 对应的托管私钥对 32 字节 digest 签名，返回 65 字节 r||s||v，v 为 0/1 恢复 id；
+// flashbots searcher 私钥在这里被拒绝，只能用于 bundle 认证
+func (c ChainAdaptor) signDigest(publicKey string, digest common.Hash) ([]byte, error) {
+	if c.isSearcherKey(publicKey) {
+		return nil, errSearcherKeyReserved
+	}
+	return c.signWithKey(publicKey, digest)
+}
+
+// signWithKey 与 signDigest 相同，但不检查 searcher 私钥
+func (c ChainAdaptor) signWithKey(publicKey string, digest common.Hash) ([]byte, error) {
+	if _, err := parsePublicKey(publicKey); err != nil {
+		return nil, err
+	}
+	privKey, ok := c.db.GetPrivKey(publicKey)
+	if !ok {
+		return nil, errPrivateKeyNotFound
+	}
+	if len(privKey) != 2*32 {
+		return nil, errInvalidPublicKey
+	}
+	signature, err := c.signer.SignMessage(privKey, digest.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("sign digest fail: %w", err)
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("decode signature fail: %w", err)
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("invalid signature length: %d", len(sig))
+	}
+	return sig, nil
+}
+
+// toWalletSignature 把 0/1 恢复 id 转成钱包与 ecrecover 使用的 27/28
+func toWalletSignature(sig []byte) []byte {
+	walletSig := make([]byte, len(sig))
+	copy(walletSig, sig)
+	walletSig[crypto.RecoveryIDOffset] += 27
+	return walletSig
+}
+
+// parseMessageHash 解析带或不带 0x 前缀的 hex 字符串，必须正好 32 字节
+func parseMessageHash(messageHash string) (common.Hash, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(messageHash, "0x"))
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, errInvalidMessageHash
+	}
+	return common.BytesToHash(b), nil
+}
+
+// parsePublicKey 解析 secp256k1 公钥，拒绝为 Solana 创建的 ed25519 等其他公钥，避免其进入 ECDSA 签名
+func parsePublicKey(publicKey string) (*ecdsa.PublicKey, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(publicKey, "0x"))
+	if err != nil {
+		return nil, errInvalidPublicKey
+	}
+	var pub *ecdsa.PublicKey
+	switch len(b) {
+	case 65:
+		pub, err = crypto.UnmarshalPubkey(b)
+	case 33:
+		pub, err = crypto.DecompressPubkey(b)
+	default:
+		return nil, errInvalidPublicKey
+	}
+	if err != nil {
+		return nil, errInvalidPublicKey
+	}
+	return pub, nil
+}
+
+// publicKeyToAddress 推导公钥签名所对应的以太坊地址
+func publicKeyToAddress(publicKey string) (common.Address, error) {
+	pub, err := parsePublicKey(publicKey)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
 }
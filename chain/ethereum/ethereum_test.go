@@ -0,0 +1,473 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/Brant-Liang/wallet-sign/leveldb"
+	"github.com/Brant-Liang/wallet-sign/ssm"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testToAddress = "0x35096AD62E57e86032a3Bb35aDaCF2240d55421D"
+
+func newTestAdaptor(t *testing.T) (*ChainAdaptor, string) {
+	t.Helper()
+	db, err := leveldb.NewKeyStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("new key store: %v", err)
+	}
+	signer := ssm.NewEcdsaSigner()
+	privKey, pubKey, _, err := signer.CreateKeyPair()
+	if err != nil {
+		t.Fatalf("create key pair: %v", err)
+	}
+	address, err := publicKeyToAddress(pubKey)
+	if err != nil {
+		t.Fatalf("derive address: %v", err)
+	}
+	if ok := db.StoreKeys([]leveldb.Key{{PrivateKey: privKey, Pubkey: pubKey, Address: address.Hex()}}); !ok {
+		t.Fatal("store keys fail")
+	}
+	return &ChainAdaptor{signer: signer, db: db}, pubKey
+}
+
+func TestSignTransactionMessage(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	digest := crypto.Keccak256Hash([]byte("wallet-sign"))
+
+	resp, err := c.SignTransactionMessage(context.Background(), &wallet.GetSignTransactionMessageRequest{
+		PublicKey:   pubKey,
+		MessageHash: digest.Hex(),
+	})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign fail: resp=%v err=%v", resp, err)
+	}
+	sig, _ := hex.DecodeString(resp.Signature)
+	if len(sig) != 65 {
+		t.Fatalf("signature length: got %d, want 65", len(sig))
+	}
+	recovered, err := crypto.Ecrecover(digest[:], sig)
+	if err != nil {
+		t.Fatalf("ecrecover: %v", err)
+	}
+	if hex.EncodeToString(recovered) != pubKey {
+		t.Errorf("recovered public key mismatch")
+	}
+}
+
+func TestSignTransactionMessageRejects(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	_, otherPubKey, _, _ := ssm.NewEcdsaSigner().CreateKeyPair()
+	_, edPubKey, _, _ := ssm.NewEdDSASigner().CreateKeyPair()
+	digest := crypto.Keccak256Hash([]byte("wallet-sign")).Hex()
+
+	cases := map[string]*wallet.GetSignTransactionMessageRequest{
+		"short hash":   {PublicKey: pubKey, MessageHash: "0x1234"},
+		"non hex hash": {PublicKey: pubKey, MessageHash: "0xzz" + digest[4:]},
+		"unknown key":  {PublicKey: otherPubKey, MessageHash: digest},
+		"wrong curve":  {PublicKey: edPubKey, MessageHash: digest},
+	}
+	for name, req := range cases {
+		resp, err := c.SignTransactionMessage(context.Background(), req)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", name, err)
+			continue
+		}
+		if resp.Code != wallet.ReturnCode_ERROR || resp.Message == "" {
+			t.Errorf("%s: expected error response, got %v", name, resp)
+		}
+	}
+}
+
+func encodeTxBody(t *testing.T, body interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("marshal tx body: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func TestBuildAndSignBatchTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	good := encodeTxBody(t, Eip1559DynamicFeeTx{
+		ChainId:              "1",
+		Nonce:                7,
+		GasLimit:             21000,
+		MaxFeePerGas:         "30000000000",
+		MaxPriorityFeePerGas: "1000000000",
+		TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "1000"},
+	})
+	bad := encodeTxBody(t, Eip1559DynamicFeeTx{ChainId: "not-a-number"})
+
+	resp, err := c.BuildAndSignBatchTransaction(context.Background(), &wallet.BuildAndSignBatchTransactionRequest{
+		TxMsg: []*wallet.TransactionMessage{
+			{PublicKey: pubKey, TxBase64Body: good},
+			{PublicKey: pubKey, TxBase64Body: bad},
+			{PublicKey: pubKey, TxBase64Body: good},
+		},
+	})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("batch fail: resp=%v err=%v", resp, err)
+	}
+	if len(resp.TxWithSign) != 3 {
+		t.Fatalf("result count: got %d, want 3", len(resp.TxWithSign))
+	}
+	wantCodes := []wallet.ReturnCode{wallet.ReturnCode_SUCCESS, wallet.ReturnCode_ERROR, wallet.ReturnCode_SUCCESS}
+	for i, item := range resp.TxWithSign {
+		if item.Code != wantCodes[i] {
+			t.Errorf("item %d: got code %v (%s), want %v", i, item.Code, item.Message, wantCodes[i])
+		}
+	}
+	if resp.TxWithSign[0].TxHash != resp.TxWithSign[2].TxHash {
+		t.Errorf("identical items produced different tx hashes")
+	}
+}
+
+func TestBuildAndSignLegacyTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	body := encodeTxBody(t, LegacyFeeTx{
+		TxType:    TxTypeLegacy,
+		ChainId:   "56",
+		Nonce:     3,
+		GasLimit:  21000,
+		GasPrice:  5_000_000_000,
+		TxPayload: TxPayload{ToAddress: testToAddress, Amount: "1000"},
+	})
+
+	resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{
+		PublicKey:    pubKey,
+		TxBase64Body: body,
+	})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign fail: resp=%v err=%v", resp, err)
+	}
+	tx := decodeSignedTx(t, resp.SignedTx)
+	if tx.Type() != types.LegacyTxType || !tx.Protected() {
+		t.Fatalf("want EIP-155 protected legacy tx, got type %d protected %v", tx.Type(), tx.Protected())
+	}
+	if tx.ChainId().Int64() != 56 {
+		t.Errorf("chain id: got %v, want 56", tx.ChainId())
+	}
+	assertSender(t, tx, pubKey)
+	if tx.Hash().Hex() != resp.TxHash {
+		t.Errorf("tx hash mismatch: %s != %s", tx.Hash().Hex(), resp.TxHash)
+	}
+}
+
+func decodeSignedTx(t *testing.T, rawHex string) *types.Transaction {
+	t.Helper()
+	raw, err := hexutil.Decode(rawHex)
+	if err != nil {
+		t.Fatalf("decode signed tx hex: %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("unmarshal signed tx: %v", err)
+	}
+	return tx
+}
+
+func assertSender(t *testing.T, tx *types.Transaction, pubKey string) {
+	t.Helper()
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		t.Fatalf("recover sender: %v", err)
+	}
+	pubKeyBytes, _ := hex.DecodeString(pubKey)
+	pub, _ := crypto.UnmarshalPubkey(pubKeyBytes)
+	if want := crypto.PubkeyToAddress(*pub); sender != want {
+		t.Errorf("sender: got %s, want %s", sender, want)
+	}
+}
+
+func TestBuildAndSignAccessListTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	body := encodeTxBody(t, Eip2930AccessListTx{
+		TxType:   TxTypeAccessList,
+		ChainId:  "1",
+		Nonce:    1,
+		GasLimit: 60000,
+		GasPrice: "20000000000",
+		AccessList: []AccessTuple{{
+			Address:     testToAddress,
+			StorageKeys: []string{"0x0000000000000000000000000000000000000000000000000000000000000001"},
+		}},
+		TxPayload: TxPayload{ToAddress: testToAddress, Amount: "1"},
+	})
+
+	resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{
+		PublicKey:    pubKey,
+		TxBase64Body: body,
+	})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign fail: resp=%v err=%v", resp, err)
+	}
+	tx := decodeSignedTx(t, resp.SignedTx)
+	if tx.Type() != types.AccessListTxType || len(tx.AccessList()) != 1 {
+		t.Fatalf("want access list tx with one tuple, got type %d list %v", tx.Type(), tx.AccessList())
+	}
+	assertSender(t, tx, pubKey)
+}
+
+func TestBuildAndSignNftTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	nft := "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d"
+	sender, _ := publicKeyToAddress(pubKey)
+	from := sender.Hex()
+	cases := map[string]struct {
+		payload TxPayload
+		ok      bool
+	}{
+		"erc721":           {TxPayload{FromAddress: from, ToAddress: testToAddress, ContractAddress: nft, TokenType: TokenTypeErc721, TokenId: "1"}, true},
+		"erc1155":          {TxPayload{FromAddress: from, ToAddress: testToAddress, ContractAddress: nft, TokenType: TokenTypeErc1155, TokenId: "1", Amount: "2", TokenData: "0x01"}, true},
+		"bad token id":     {TxPayload{FromAddress: from, ToAddress: testToAddress, ContractAddress: nft, TokenType: TokenTypeErc721, TokenId: "abc"}, false},
+		"missing contract": {TxPayload{FromAddress: from, ToAddress: testToAddress, TokenType: TokenTypeErc721, TokenId: "1"}, false},
+		"unknown type":     {TxPayload{FromAddress: from, ToAddress: testToAddress, ContractAddress: nft, TokenType: "erc404"}, false},
+	}
+	var nonce uint64
+	for name, tc := range cases {
+		// 每笔交易使用不同 nonce，同 nonce 的不同交易会被拒绝
+		nonce++
+		body := encodeTxBody(t, Eip1559DynamicFeeTx{
+			ChainId:              "1",
+			Nonce:                nonce,
+			GasLimit:             100000,
+			MaxFeePerGas:         "30000000000",
+			MaxPriorityFeePerGas: "1000000000",
+			TxPayload:            tc.payload,
+		})
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: body})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if (resp.Code == wallet.ReturnCode_SUCCESS) != tc.ok {
+			t.Errorf("%s: got code %v (%s)", name, resp.Code, resp.Message)
+			continue
+		}
+		if tc.ok {
+			tx := decodeSignedTx(t, resp.SignedTx)
+			if tx.To() == nil || *tx.To() != common.HexToAddress(nft) || tx.Value().Sign() != 0 {
+				t.Errorf("%s: nft transfer must call the token contract with zero value", name)
+			}
+		}
+	}
+}
+
+func TestBuildAndSignFromAddress(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	sender, _ := publicKeyToAddress(pubKey)
+	cases := map[string]struct {
+		publicKey   string
+		fromAddress string
+		ok          bool
+	}{
+		"no claim":           {pubKey, "", true},
+		"matching from":      {pubKey, strings.ToLower(sender.Hex()), true},
+		"mismatched from":    {pubKey, testToAddress, false},
+		"sign by address":    {"", sender.Hex(), true},
+		"unindexed address":  {"", testToAddress, false},
+		"no key and no from": {"", "", false},
+		"invalid from":       {pubKey, "0x1234", false},
+	}
+	for name, tc := range cases {
+		body := encodeTxBody(t, Eip1559DynamicFeeTx{
+			ChainId:              "1",
+			GasLimit:             21000,
+			MaxFeePerGas:         "30000000000",
+			MaxPriorityFeePerGas: "1000000000",
+			TxPayload:            TxPayload{FromAddress: tc.fromAddress, ToAddress: testToAddress, Amount: "1"},
+		})
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: tc.publicKey, TxBase64Body: body})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if (resp.Code == wallet.ReturnCode_SUCCESS) != tc.ok {
+			t.Errorf("%s: got code %v (%s)", name, resp.Code, resp.Message)
+			continue
+		}
+		if tc.ok {
+			assertSender(t, decodeSignedTx(t, resp.SignedTx), pubKey)
+		}
+	}
+}
+
+func TestBuildAndSignContractDeployment(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	body := encodeTxBody(t, Eip1559DynamicFeeTx{
+		ChainId:              "1",
+		Nonce:                5,
+		GasLimit:             500000,
+		MaxFeePerGas:         "30000000000",
+		MaxPriorityFeePerGas: "1000000000",
+		TxPayload: TxPayload{
+			Bytecode:        "0x6080604052",
+			ConstructorArgs: "0x000000000000000000000000000000000000000000000000000000000000002a",
+		},
+	})
+	resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: body})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign fail: resp=%v err=%v", resp, err)
+	}
+	tx := decodeSignedTx(t, resp.SignedTx)
+	if tx.To() != nil || len(tx.Data()) != 5+32 {
+		t.Fatalf("want contract creation with bytecode and args, got to=%v dataLen=%d", tx.To(), len(tx.Data()))
+	}
+	sender, _ := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if want := crypto.CreateAddress(sender, 5).Hex(); resp.ContractAddress != want {
+		t.Errorf("contract address: got %s, want %s", resp.ContractAddress, want)
+	}
+
+	body = encodeTxBody(t, Eip1559DynamicFeeTx{
+		ChainId:              "1",
+		GasLimit:             21000,
+		MaxFeePerGas:         "30000000000",
+		MaxPriorityFeePerGas: "1000000000",
+		TxPayload:            TxPayload{Amount: "1"},
+	})
+	resp, _ = c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: body})
+	if resp.Code != wallet.ReturnCode_ERROR {
+		t.Errorf("transfer without to_address or bytecode must be rejected")
+	}
+}
+
+func TestBuildAndSignBlobTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	for _, version := range []uint8{types.BlobSidecarVersion0, types.BlobSidecarVersion1} {
+		body := encodeTxBody(t, Eip4844BlobTx{
+			TxType:               TxTypeBlob,
+			ChainId:              "1",
+			Nonce:                9,
+			GasLimit:             21000,
+			MaxFeePerGas:         "30000000000",
+			MaxPriorityFeePerGas: "1000000000",
+			MaxFeePerBlobGas:     "1000000",
+			Blobs:                []string{"0x0001020304", "0x"},
+			SidecarVersion:       version,
+			TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "0"},
+		})
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: body})
+		if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Fatalf("sidecar v%d: sign fail: resp=%v err=%v", version, resp, err)
+		}
+		tx := decodeSignedTx(t, resp.SignedTx)
+		sidecar := tx.BlobTxSidecar()
+		if tx.Type() != types.BlobTxType || sidecar == nil || sidecar.Version != version {
+			t.Fatalf("sidecar v%d: want blob tx in network form", version)
+		}
+		if err := sidecar.ValidateBlobCommitmentHashes(tx.BlobHashes()); err != nil {
+			t.Errorf("sidecar v%d: %v", version, err)
+		}
+		if tx.Hash().Hex() != resp.TxHash {
+			t.Errorf("sidecar v%d: tx hash mismatch", version)
+		}
+		assertSender(t, tx, pubKey)
+	}
+}
+
+func TestBuildAndSignSetCodeTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	privKey, otherPubKey, _, _ := ssm.NewEcdsaSigner().CreateKeyPair()
+	c.db.StoreKeys([]leveldb.Key{{PrivateKey: privKey, Pubkey: otherPubKey}})
+	delegate := "0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B"
+	c.conf = &config.Config{AuthorizationDelegates: []string{delegate}, AllowAnyChainAuthorization: true}
+
+	body := encodeTxBody(t, Eip7702SetCodeTx{
+		TxType:               TxTypeSetCode,
+		ChainId:              "1",
+		Nonce:                0,
+		GasLimit:             100000,
+		MaxFeePerGas:         "30000000000",
+		MaxPriorityFeePerGas: "1000000000",
+		Authorizations: []Eip7702Authorization{
+			{ChainId: "1", Address: delegate, Nonce: 1, PublicKey: pubKey},
+			{ChainId: "0", Address: delegate, Nonce: 4, PublicKey: otherPubKey},
+		},
+		TxPayload: TxPayload{ToAddress: testToAddress, Amount: "0"},
+	})
+	resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: body})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign fail: resp=%v err=%v", resp, err)
+	}
+	tx := decodeSignedTx(t, resp.SignedTx)
+	if tx.Type() != types.SetCodeTxType || len(tx.SetCodeAuthorizations()) != 2 {
+		t.Fatalf("want set code tx with two authorizations")
+	}
+	assertSender(t, tx, pubKey)
+	for i, wantKey := range []string{pubKey, otherPubKey} {
+		auth := tx.SetCodeAuthorizations()[i]
+		authority, err := auth.Authority()
+		if err != nil {
+			t.Fatalf("authorization %d: recover authority: %v", i, err)
+		}
+		if want, _ := publicKeyToAddress(wantKey); authority != want {
+			t.Errorf("authorization %d: authority %s, want %s", i, authority, want)
+		}
+	}
+
+	body = encodeTxBody(t, Eip7702SetCodeTx{
+		TxType:               TxTypeSetCode,
+		ChainId:              "1",
+		GasLimit:             100000,
+		MaxFeePerGas:         "30000000000",
+		MaxPriorityFeePerGas: "1000000000",
+		TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "0"},
+	})
+	resp, _ = c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: body})
+	if resp.Code != wallet.ReturnCode_ERROR {
+		t.Errorf("set code tx without authorizations must be rejected")
+	}
+}
+
+func TestSetCodeAuthorizationPolicy(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	delegate := "0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B"
+	c.conf = &config.Config{AuthorizationDelegates: []string{delegate}}
+	sign := func(auth Eip7702Authorization) *wallet.BuildAndSignTransactionResponse {
+		auth.PublicKey = pubKey
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{
+			PublicKey: pubKey,
+			TxBase64Body: encodeTxBody(t, Eip7702SetCodeTx{
+				TxType:               TxTypeSetCode,
+				ChainId:              "1",
+				GasLimit:             100000,
+				MaxFeePerGas:         "30000000000",
+				MaxPriorityFeePerGas: "1000000000",
+				Authorizations:       []Eip7702Authorization{auth},
+				TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "0"},
+			}),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return resp
+	}
+
+	rejected := map[string]struct {
+		auth Eip7702Authorization
+		want string
+	}{
+		"any chain":        {Eip7702Authorization{ChainId: "0", Address: delegate, Nonce: 1}, "allow_any_chain_authorization"},
+		"other chain":      {Eip7702Authorization{ChainId: "137", Address: delegate, Nonce: 1}, "does not match transaction chain ID 1"},
+		"unknown delegate": {Eip7702Authorization{ChainId: "1", Address: testToAddress, Nonce: 1}, "not in authorization_delegates"},
+	}
+	for name, tc := range rejected {
+		if resp := sign(tc.auth); resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, tc.want) {
+			t.Errorf("%s: got %v, want error containing %q", name, resp, tc.want)
+		}
+	}
+	// 零地址即清除委托，不需要在 authorization_delegates 中
+	if resp := sign(Eip7702Authorization{ChainId: "1", Address: common.Address{}.Hex(), Nonce: 1}); resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Errorf("clearing the delegation must be allowed: %v", resp)
+	}
+}
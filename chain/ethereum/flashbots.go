@@ -0,0 +1,212 @@
+package ethereum
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxBundleTxNum 限制单个 bundle 的交易数，relay 对 bundle 大小同样有限制
+const maxBundleTxNum = 100
+
+var (
+	errSearcherKeyNotConfigured = errors.New("flashbots_searcher_key is not configured")
+	errSearcherKeyReserved      = errors.New("searcher key is reserved for flashbots relay authentication")
+)
+
+// sendBundleParams 是 eth_sendBundle 的参数，字段名与 Flashbots relay 的 JSON-RPC 约定一致
+type sendBundleParams struct {
+	Txs               []string       `json:"txs"`
+	BlockNumber       hexutil.Uint64 `json:"blockNumber"`
+	MinTimestamp      uint64         `json:"minTimestamp,omitempty"`
+	MaxTimestamp      uint64         `json:"maxTimestamp,omitempty"`
+	RevertingTxHashes []string       `json:"revertingTxHashes,omitempty"`
+	ReplacementUuid   string         `json:"replacementUuid,omitempty"`
+}
+
+type jsonRpcRequest struct {
+	JsonRpc string        `json:"jsonrpc"`
+	Id      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// checkBundleRequest 校验 bundle 的目标区块、时间窗口与交易列表，不涉及签名
+func checkBundleRequest(req *wallet.SignFlashbotsBundleRequest) error {
+	if len(req.Txs) == 0 {
+		return errors.New("bundle transaction list is empty")
+	}
+	if len(req.Txs) > maxBundleTxNum {
+		return fmt.Errorf("bundle size must be <= %d", maxBundleTxNum)
+	}
+	if req.BlockNumber == 0 {
+		return errors.New("block_number is required")
+	}
+	if req.MaxTimestamp != 0 && req.MinTimestamp > req.MaxTimestamp {
+		return fmt.Errorf("min_timestamp %d is after max_timestamp %d", req.MinTimestamp, req.MaxTimestamp)
+	}
+	for i, bundleTx := range req.Txs {
+		if (bundleTx.GetTx() == nil) == (bundleTx.GetSignedTx() == "") {
+			return fmt.Errorf("bundle tx %d: exactly one of tx and signed_tx is required", i)
+		}
+	}
+	return nil
+}
+
+// bundleTxError 给错误加上交易在 bundle 中的位置，reject reason 保持不变
+func bundleTxError(index int, err error) error {
+	return fmt.Errorf("bundle tx %d: %w", index, err)
+}
+
+// signBundleTxs 先对 bundle 中每笔待签交易完成解析、策略、预执行与 nonce 检查，全部通过后才签名，
+// 最后在一个 batch 中写入 nonce 记录；任何一笔失败都不会留下签名或 nonce 记录，调用方调整 fee 后可直接重试。
+// 已签名的交易只解码校验，不再签名
+func (c ChainAdaptor) signBundleTxs(bundleTxs []*wallet.BundleTransaction) ([]*wallet.TransactionWithSign, error) {
+	txWithSignList := make([]*wallet.TransactionWithSign, len(bundleTxs))
+	prepared := make([]*preparedTx, 0, len(bundleTxs))
+	indexes := make([]int, 0, len(bundleTxs))
+	for i, bundleTx := range bundleTxs {
+		txMsg := bundleTx.GetTx()
+		if txMsg == nil {
+			decoded, err := c.decodeBundleSignedTx(bundleTx.SignedTx)
+			if err != nil {
+				return nil, bundleTxError(i, err)
+			}
+			txWithSignList[i] = decoded
+			continue
+		}
+		p, err := c.prepareTx(txMsg.PublicKey, txMsg.TxBase64Body, txMsg.UnsignedTx, signOptions{
+			replacement:       txMsg.Replacement,
+			replacementReason: txMsg.ReplacementReason,
+			simulation:        txMsg.Simulation,
+		})
+		if err != nil {
+			return nil, bundleTxError(i, err)
+		}
+		prepared = append(prepared, p)
+		indexes = append(indexes, i)
+	}
+
+	// nonce 检查、签名与记录在 bundle 涉及的全部 sender 的锁内完成
+	defer lockSenderNonces(prepared)()
+	usedNonces := make(map[string]int, len(prepared))
+	for j, p := range prepared {
+		// bundle 内的交易尚未记录，同一 sender 的重复 nonce 需要在这里拦截
+		key := fmt.Sprintf("%s:%s:%d", p.unsigned.chainID, p.sender.Hex(), p.tx.Nonce())
+		if first, ok := usedNonces[key]; ok {
+			return nil, bundleTxError(indexes[j], policyError(wallet.RejectReason_REJECT_REASON_NONCE_CONFLICT, "nonce %d of %s is already used by bundle tx %d", p.tx.Nonce(), p.sender, first))
+		}
+		usedNonces[key] = indexes[j]
+		if err := c.checkPreparedNonce(p); err != nil {
+			return nil, bundleTxError(indexes[j], err)
+		}
+	}
+	for j, p := range prepared {
+		if err := c.signPreparedTx(p); err != nil {
+			return nil, bundleTxError(indexes[j], err)
+		}
+	}
+	if err := c.recordSignedNonces(prepared); err != nil {
+		log.Error("record bundle signed nonces fail", "err", err)
+		return nil, fmt.Errorf("record signed nonce fail: %w", err)
+	}
+	for j, p := range prepared {
+		txWithSignList[indexes[j]] = c.signedTxResult(p)
+	}
+	return txWithSignList, nil
+}
+
+// decodeBundleSignedTx 解码 bundle 中他人已签名的交易并校验 chain_id
+func (c ChainAdaptor) decodeBundleSignedTx(signedTx string) (*wallet.TransactionWithSign, error) {
+	raw, err := DecodeRawTxBytes(signedTx)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := DecodeRawTransaction(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.Signed {
+		return nil, errors.New("signed_tx is not signed")
+	}
+	tx := decoded.Tx
+	if tx.Protected() {
+		if err := c.network.checkChainID(decoded.ChainID); err != nil {
+			return nil, err
+		}
+	}
+	sender, err := decoded.Sender()
+	if err != nil {
+		return nil, fmt.Errorf("recover sender fail: %w", err)
+	}
+	return &wallet.TransactionWithSign{
+		TxHash:        tx.Hash().Hex(),
+		SignedTx:      hexutil.Encode(raw),
+		DisplayAmount: c.network.displayAmount(tx),
+		Intent:        c.network.txIntent(tx, decoded.ChainID, &sender),
+	}, nil
+}
+
+// buildSendBundleBody 按交易顺序生成 eth_sendBundle 的 JSON-RPC 请求体
+func buildSendBundleBody(req *wallet.SignFlashbotsBundleRequest, txWithSignList []*wallet.TransactionWithSign) ([]byte, error) {
+	params := sendBundleParams{
+		Txs:             make([]string, len(txWithSignList)),
+		BlockNumber:     hexutil.Uint64(req.BlockNumber),
+		MinTimestamp:    req.MinTimestamp,
+		MaxTimestamp:    req.MaxTimestamp,
+		ReplacementUuid: req.ReplacementUuid,
+	}
+	for i, item := range txWithSignList {
+		params.Txs[i] = item.SignedTx
+		if req.Txs[i].CanRevert {
+			params.RevertingTxHashes = append(params.RevertingTxHashes, item.TxHash)
+		}
+	}
+	return json.Marshal(jsonRpcRequest{JsonRpc: "2.0", Id: 1, Method: "eth_sendBundle", Params: []interface{}{params}})
+}
+
+// signFlashbotsPayload 生成 X-Flashbots-Signature：对 keccak256(body) 的 0x hex 字符串做 personal_sign，
+// 格式为 "searcher 地址:签名"
+func (c ChainAdaptor) signFlashbotsPayload(body []byte) (string, common.Address, error) {
+	publicKey := c.searcherKey()
+	if publicKey == "" {
+		return "", common.Address{}, errSearcherKeyNotConfigured
+	}
+	searcher, err := publicKeyToAddress(publicKey)
+	if err != nil {
+		return "", common.Address{}, fmt.Errorf("invalid flashbots_searcher_key: %w", err)
+	}
+	digest := PersonalMessageHash([]byte(crypto.Keccak256Hash(body).Hex()))
+	sig, err := c.signWithKey(publicKey, digest)
+	if err != nil {
+		return "", common.Address{}, err
+	}
+	return searcher.Hex() + ":" + hexutil.Encode(toWalletSignature(sig)), searcher, nil
+}
+
+func (c ChainAdaptor) searcherKey() string {
+	if c.conf == nil {
+		return ""
+	}
+	return c.conf.FlashbotsSearcherKey
+}
+
+// isSearcherKey 按地址比较，压缩与非压缩格式的同一公钥视为同一把密钥
+func (c ChainAdaptor) isSearcherKey(publicKey string) bool {
+	searcherKey := c.searcherKey()
+	if searcherKey == "" {
+		return false
+	}
+	searcher, err := publicKeyToAddress(searcherKey)
+	if err != nil {
+		return false
+	}
+	address, err := publicKeyToAddress(publicKey)
+	return err == nil && address == searcher
+}
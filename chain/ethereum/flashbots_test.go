@@ -0,0 +1,199 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSignFlashbotsBundle(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	searcherKey := storeTestKey(t, c.db)
+	searcher, _ := publicKeyToAddress(searcherKey)
+
+	// 他人已签名的目标交易，bundle 中排在我们的交易之前
+	victimKey, _ := crypto.GenerateKey()
+	victimTx, _ := types.SignNewTx(victimKey, types.LatestSignerForChainID(common.Big1), &types.DynamicFeeTx{
+		ChainID:   common.Big1,
+		Gas:       21000,
+		GasFeeCap: big.NewInt(30000000000),
+		GasTipCap: big.NewInt(1000000000),
+		To:        &common.Address{},
+	})
+	victimRaw, _ := victimTx.MarshalBinary()
+	bundleTx := func(publicKey string, nonce uint64) *wallet.TransactionMessage {
+		return &wallet.TransactionMessage{
+			PublicKey: publicKey,
+			TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+				ChainId:              "1",
+				Nonce:                nonce,
+				GasLimit:             21000,
+				MaxFeePerGas:         "30000000000",
+				MaxPriorityFeePerGas: "2000000000",
+				TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "1"},
+			}),
+		}
+	}
+	req := &wallet.SignFlashbotsBundleRequest{
+		Txs: []*wallet.BundleTransaction{
+			{SignedTx: hexutil.Encode(victimRaw)},
+			{Tx: bundleTx(pubKey, 0), CanRevert: true},
+		},
+		BlockNumber:  0x1234,
+		MinTimestamp: 1700000000,
+	}
+
+	resp, _ := c.SignFlashbotsBundle(context.Background(), req)
+	if resp.Code != wallet.ReturnCode_ERROR || resp.Message != errSearcherKeyNotConfigured.Error() {
+		t.Fatalf("bundle without searcher key: got %v", resp)
+	}
+	c.conf = &config.Config{FlashbotsSearcherKey: searcherKey}
+
+	resp, err := c.SignFlashbotsBundle(context.Background(), req)
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign bundle fail: %v %v", err, resp)
+	}
+	if len(resp.TxWithSign) != 2 || resp.TxWithSign[0].TxHash != victimTx.Hash().Hex() || resp.SearcherAddress != searcher.Hex() {
+		t.Fatalf("unexpected bundle result: %v", resp)
+	}
+	ourTx := decodeSignedTx(t, resp.TxWithSign[1].SignedTx)
+	assertSender(t, ourTx, pubKey)
+
+	var body struct {
+		Method string             `json:"method"`
+		Params []sendBundleParams `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &body); err != nil || body.Method != "eth_sendBundle" || len(body.Params) != 1 {
+		t.Fatalf("unexpected bundle body %s: %v", resp.Body, err)
+	}
+	params := body.Params[0]
+	if len(params.Txs) != 2 || params.Txs[0] != hexutil.Encode(victimRaw) || params.Txs[1] != resp.TxWithSign[1].SignedTx {
+		t.Errorf("bundle txs must keep request order: %v", params.Txs)
+	}
+	if params.BlockNumber != 0x1234 || params.MinTimestamp != 1700000000 || params.MaxTimestamp != 0 {
+		t.Errorf("unexpected bundle target: %+v", params)
+	}
+	if len(params.RevertingTxHashes) != 1 || params.RevertingTxHashes[0] != ourTx.Hash().Hex() {
+		t.Errorf("reverting tx hashes: got %v", params.RevertingTxHashes)
+	}
+	if !strings.Contains(resp.Body, `"blockNumber":"0x1234"`) {
+		t.Errorf("block number must be hex encoded: %s", resp.Body)
+	}
+
+	// X-Flashbots-Signature = 地址:personal_sign(keccak256(body) 的 hex 字符串)
+	address, signature, ok := strings.Cut(resp.FlashbotsSignature, ":")
+	if !ok || address != searcher.Hex() {
+		t.Fatalf("unexpected signature header: %s", resp.FlashbotsSignature)
+	}
+	sig := hexutil.MustDecode(signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+	digest := PersonalMessageHash([]byte(crypto.Keccak256Hash([]byte(resp.Body)).Hex()))
+	recovered, err := crypto.SigToPub(digest.Bytes(), sig)
+	if err != nil || crypto.PubkeyToAddress(*recovered) != searcher {
+		t.Errorf("flashbots signature must recover to searcher %s", searcher.Hex())
+	}
+
+	// searcher 密钥只能用于 relay 认证
+	req.Txs = []*wallet.BundleTransaction{{Tx: bundleTx(searcherKey, 0)}}
+	if resp, _ = c.SignFlashbotsBundle(context.Background(), req); resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, errSearcherKeyReserved.Error()) {
+		t.Errorf("searcher key must not sign bundle txs: %v", resp)
+	}
+	personal, _ := c.SignPersonalMessage(context.Background(), &wallet.SignPersonalMessageRequest{PublicKey: searcherKey, Message: "hello"})
+	if personal.Code != wallet.ReturnCode_ERROR || personal.Message != errSearcherKeyReserved.Error() {
+		t.Errorf("searcher key must not sign messages: %v", personal)
+	}
+}
+
+func TestFlashbotsBundleValidation(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	c.conf = &config.Config{FlashbotsSearcherKey: storeTestKey(t, c.db)}
+	unsigned := &wallet.BundleTransaction{Tx: &wallet.TransactionMessage{PublicKey: pubKey}}
+	cases := map[string]struct {
+		req  *wallet.SignFlashbotsBundleRequest
+		want string
+	}{
+		"empty":              {&wallet.SignFlashbotsBundleRequest{BlockNumber: 1}, "bundle transaction list is empty"},
+		"no block":           {&wallet.SignFlashbotsBundleRequest{Txs: []*wallet.BundleTransaction{unsigned}}, "block_number is required"},
+		"timestamp window":   {&wallet.SignFlashbotsBundleRequest{Txs: []*wallet.BundleTransaction{unsigned}, BlockNumber: 1, MinTimestamp: 2, MaxTimestamp: 1}, "is after max_timestamp"},
+		"tx and signed tx":   {&wallet.SignFlashbotsBundleRequest{Txs: []*wallet.BundleTransaction{{Tx: unsigned.Tx, SignedTx: "0x01"}}, BlockNumber: 1}, "exactly one of tx and signed_tx"},
+		"neither":            {&wallet.SignFlashbotsBundleRequest{Txs: []*wallet.BundleTransaction{{}}, BlockNumber: 1}, "exactly one of tx and signed_tx"},
+		"unsigned signed_tx": {&wallet.SignFlashbotsBundleRequest{Txs: []*wallet.BundleTransaction{{SignedTx: unsignedLegacyHex()}}, BlockNumber: 1}, "signed_tx is not signed"},
+	}
+	for name, tc := range cases {
+		resp, _ := c.SignFlashbotsBundle(context.Background(), tc.req)
+		if resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, tc.want) {
+			t.Errorf("%s: got %q, want %q", name, resp.Message, tc.want)
+		}
+	}
+}
+
+func TestFlashbotsBundleFailureLeavesNoNonceRecord(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{Name: "Ethereum", ChainId: 1, Symbol: "ETH", MaxFeePerGas: "500000000000"})
+	c.conf = &config.Config{FlashbotsSearcherKey: storeTestKey(t, c.db)}
+	sender, _ := publicKeyToAddress(pubKey)
+	bundleTx := func(nonce uint64, maxFee string) *wallet.BundleTransaction {
+		return &wallet.BundleTransaction{Tx: &wallet.TransactionMessage{
+			PublicKey: pubKey,
+			TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+				ChainId:              "1",
+				Nonce:                nonce,
+				GasLimit:             21000,
+				MaxFeePerGas:         maxFee,
+				MaxPriorityFeePerGas: "2000000000",
+				TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "1"},
+			}),
+		}}
+	}
+
+	// 第二笔超过 fee 上限：整个 bundle 被拒绝，第一笔也不能留下 nonce 记录
+	resp, _ := c.SignFlashbotsBundle(context.Background(), &wallet.SignFlashbotsBundleRequest{
+		Txs:         []*wallet.BundleTransaction{bundleTx(0, "30000000000"), bundleTx(1, "600000000000")},
+		BlockNumber: 1,
+	})
+	if resp.Code != wallet.ReturnCode_ERROR || resp.RejectReason != wallet.RejectReason_REJECT_REASON_MAX_FEE_TOO_HIGH || !strings.HasPrefix(resp.Message, "bundle tx 1:") {
+		t.Fatalf("bundle with a rejected tx must fail: %v", resp)
+	}
+	if _, ok, _ := c.db.GetSignedNonce("1", sender.Hex(), 0); ok {
+		t.Fatal("failed bundle must not record nonces")
+	}
+
+	// 调整 fee 后重试，无需 replacement
+	resp, _ = c.SignFlashbotsBundle(context.Background(), &wallet.SignFlashbotsBundleRequest{
+		Txs:         []*wallet.BundleTransaction{bundleTx(0, "35000000000"), bundleTx(1, "35000000000")},
+		BlockNumber: 1,
+	})
+	if resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("retry with new fees fail: %s", resp.Message)
+	}
+	for nonce, item := range resp.TxWithSign {
+		if record, ok, _ := c.db.GetSignedNonce("1", sender.Hex(), uint64(nonce)); !ok || record.TxHash != item.TxHash {
+			t.Errorf("nonce %d must be recorded after the bundle succeeds: %+v", nonce, record)
+		}
+	}
+
+	// bundle 内同一 sender 的 nonce 不能重复
+	resp, _ = c.SignFlashbotsBundle(context.Background(), &wallet.SignFlashbotsBundleRequest{
+		Txs:         []*wallet.BundleTransaction{bundleTx(2, "30000000000"), bundleTx(2, "31000000000")},
+		BlockNumber: 1,
+	})
+	if resp.Code != wallet.ReturnCode_ERROR || resp.RejectReason != wallet.RejectReason_REJECT_REASON_NONCE_CONFLICT {
+		t.Errorf("duplicate nonce in bundle must be rejected: %v", resp)
+	}
+	if _, ok, _ := c.db.GetSignedNonce("1", sender.Hex(), 2); ok {
+		t.Error("rejected bundle must not record nonces")
+	}
+}
+
+func unsignedLegacyHex() string {
+	raw, _ := types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: common.Big1, To: &common.Address{}}).MarshalBinary()
+	return hexutil.Encode(raw)
+}
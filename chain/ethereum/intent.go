@@ -0,0 +1,164 @@
+package ethereum
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	IntentNativeTransfer     = "native_transfer"
+	IntentTokenTransfer      = "token_transfer"
+	IntentTokenApproval      = "token_approval"
+	IntentOperatorApproval   = "operator_approval"
+	IntentContractCall       = "contract_call"
+	IntentContractDeployment = "contract_deployment"
+)
+
+// defaultNativeSymbol 在未配置网络时用于展示原生币金额
+const defaultNativeSymbol = "ETH"
+
+// txIntent 把交易描述成审批人可读的意图；sender 为空表示未签名交易，转出方未知
+func (n *evmNetwork) txIntent(tx *types.Transaction, chainID *big.Int, sender *common.Address) *wallet.TransactionIntent {
+	intent := &wallet.TransactionIntent{
+		Network: n.displayName(),
+		ChainId: chainID.String(),
+		Nonce:   tx.Nonce(),
+		MaxFee:  n.formatNative(maxTxFee(tx)),
+	}
+	if sender != nil {
+		intent.From = sender.Hex()
+	}
+
+	var action string
+	switch {
+	case tx.To() == nil:
+		intent.Action = IntentContractDeployment
+		action = "deploy contract"
+		if sender != nil {
+			action += " at " + crypto.CreateAddress(*sender, tx.Nonce()).Hex()
+		}
+		action += " from " + intentParty(intent.From)
+		if tx.Value().Sign() > 0 {
+			action += " with " + n.formatNative(tx.Value())
+		}
+	case len(tx.Data()) == 0:
+		intent.Action = IntentNativeTransfer
+		intent.To = tx.To().Hex()
+		intent.Amount = n.formatNative(tx.Value())
+		action = fmt.Sprintf("transfer %s from %s to %s", intent.Amount, intentParty(intent.From), intent.To)
+	default:
+		action = n.describeCall(intent, *tx.To(), tx.Data())
+		if tx.Value().Sign() > 0 {
+			action += " with " + n.formatNative(tx.Value())
+		}
+	}
+	if auths := tx.SetCodeAuthorizations(); len(auths) > 0 {
+		action += fmt.Sprintf(", with %d code delegation authorization(s)", len(auths))
+	}
+	intent.Summary = fmt.Sprintf("%s on %s, max fee %s", action, intent.Network, intent.MaxFee)
+	return intent
+}
+
+// describeCall 识别 ERC-20/721/1155 调用并填充 intent，未识别的 calldata 按通用合约调用描述
+func (n *evmNetwork) describeCall(intent *wallet.TransactionIntent, contract common.Address, data []byte) string {
+	call, err := DecodeTokenCall(data)
+	if err != nil || call == nil {
+		intent.Action = IntentContractCall
+		intent.To = contract.Hex()
+		intent.Method = hexutil.Encode(data[:min(4, len(data))])
+		return fmt.Sprintf("call %s on %s from %s", intent.Method, intent.To, intentParty(intent.From))
+	}
+	intent.Contract = contract.Hex()
+	intent.To = call.To.Hex()
+	intent.Method = call.Method
+	intent.Owner = intent.From
+	if call.From != nil {
+		// transferFrom 转出的是 from 的资产，签名者只是发起调用的一方，仍记录在 From 中
+		intent.Owner = call.From.Hex()
+	}
+	owner := intentParty(intent.Owner)
+
+	token := n.tokens().lookupAddress(contract)
+	switch {
+	case call.Method == "approve":
+		intent.Action = IntentTokenApproval
+		amount := n.formatTokenAmount(contract, call.Amount)
+		if call.Amount.Cmp(math.MaxBig256) == 0 {
+			amount = "unlimited " + n.tokenName(contract)
+		}
+		intent.Amount = amount
+		return fmt.Sprintf("approve %s to spend %s of %s", intent.To, amount, owner)
+	case call.Method == "setApprovalForAll":
+		intent.Action = IntentOperatorApproval
+		verb := "revoke"
+		if call.Approved {
+			verb = "approve"
+		}
+		return fmt.Sprintf("%s %s as operator for all %s tokens of %s", verb, intent.To, contract.Hex(), owner)
+	}
+
+	intent.Action = IntentTokenTransfer
+	var asset string
+	switch {
+	case call.Ids != nil:
+		ids := make([]string, len(call.Ids))
+		for i, id := range call.Ids {
+			ids[i] = id.String()
+		}
+		intent.TokenId = strings.Join(ids, ",")
+		asset = fmt.Sprintf("%d token ids (%s) of %s", len(call.Ids), intent.TokenId, contract.Hex())
+	case call.TokenId != nil && call.Amount != nil:
+		intent.TokenId = call.TokenId.String()
+		intent.Amount = call.Amount.String()
+		asset = fmt.Sprintf("%s of token #%s of %s", intent.Amount, intent.TokenId, contract.Hex())
+	case call.TokenId != nil:
+		intent.TokenId = call.TokenId.String()
+		asset = fmt.Sprintf("NFT #%s of %s", intent.TokenId, contract.Hex())
+	case call.Standard == TokenStandardErc20Or721 && token == nil:
+		// transferFrom 的 selector 两个标准共用，未登记的合约无法判断最后一个参数是数量还是 tokenId
+		intent.Amount = call.Amount.String()
+		asset = fmt.Sprintf("%s (erc20 amount or erc721 token id) of %s", intent.Amount, contract.Hex())
+	default:
+		intent.Amount = n.formatTokenAmount(contract, call.Amount)
+		asset = intent.Amount
+	}
+	return fmt.Sprintf("transfer %s from %s to %s", asset, owner, intent.To)
+}
+
+// formatTokenAmount 注册表中的代币按精度换算，未登记的代币给出最小单位与合约地址
+func (n *evmNetwork) formatTokenAmount(contract common.Address, amount *big.Int) string {
+	if token := n.tokens().lookupAddress(contract); token != nil {
+		return FormatTokenAmount(amount, token.decimals) + " " + token.symbol
+	}
+	return fmt.Sprintf("%s units of token %s", amount, contract.Hex())
+}
+
+func (n *evmNetwork) tokenName(contract common.Address) string {
+	if token := n.tokens().lookupAddress(contract); token != nil {
+		return token.symbol
+	}
+	return "token " + contract.Hex()
+}
+
+func (n *evmNetwork) formatNative(amount *big.Int) string {
+	symbol := defaultNativeSymbol
+	if n != nil && n.symbol != "" {
+		symbol = n.symbol
+	}
+	return FormatTokenAmount(amount, nativeDecimals) + " " + symbol
+}
+
+func intentParty(address string) string {
+	if address == "" {
+		return "unknown sender"
+	}
+	return address
+}
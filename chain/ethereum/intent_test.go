@@ -0,0 +1,102 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestTransactionIntent(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{
+		Name:    "Ethereum",
+		ChainId: 1,
+		Symbol:  "ETH",
+		Tokens:  []config.EvmToken{{Address: testUsdcAddress, Symbol: "USDC", Decimals: 6}},
+	})
+	sender, _ := publicKeyToAddress(pubKey)
+	to := common.HexToAddress(testToAddress).Hex()
+	unknown := common.HexToAddress(testTokenAddress).Hex()
+	owner := common.HexToAddress(testSafeAddress).Hex()
+
+	cases := []struct {
+		name    string
+		payload TxPayload
+		action  string
+		amount  string
+		summary string
+	}{
+		{"native", TxPayload{ToAddress: testToAddress, Amount: "0.25 ETH"}, IntentNativeTransfer, "0.25 ETH",
+			"transfer 0.25 ETH from " + sender.Hex() + " to " + to + " on Ethereum, max fee 0.003 ETH"},
+		{"registered token", TxPayload{ToAddress: testToAddress, Amount: "100 USDC"}, IntentTokenTransfer, "100 USDC",
+			"transfer 100 USDC from " + sender.Hex() + " to " + to + " on Ethereum, max fee 0.003 ETH"},
+		{"unknown token", TxPayload{ToAddress: testToAddress, ContractAddress: testTokenAddress, Amount: "1000", AllowUnknownToken: true}, IntentTokenTransfer, "1000 units of token " + unknown,
+			"transfer 1000 units of token " + unknown + " from " + sender.Hex()},
+		{"unlimited approve", TxPayload{ContractAddress: testUsdcAddress, Method: "approve(address,uint256)", Args: []byte(`["` + testToAddress + `","` + math.MaxBig256.String() + `"]`)}, IntentTokenApproval, "unlimited USDC",
+			"approve " + to + " to spend unlimited USDC of " + sender.Hex()},
+		{"nft", TxPayload{FromAddress: sender.Hex(), ToAddress: testToAddress, ContractAddress: testTokenAddress, TokenType: TokenTypeErc721, TokenId: "7"}, IntentTokenTransfer, "",
+			"transfer NFT #7 of " + unknown + " from " + sender.Hex() + " to " + to},
+		{"transferFrom", TxPayload{ContractAddress: testUsdcAddress, Method: "transferFrom(address,address,uint256)", Args: []byte(`["` + testSafeAddress + `","` + testToAddress + `","1000000"]`)}, IntentTokenTransfer, "1 USDC",
+			"transfer 1 USDC from " + owner + " to " + to},
+		{"contract call", TxPayload{ContractAddress: testTokenAddress, Data: "0x12345678", Amount: "1 ETH"}, IntentContractCall, "",
+			"call 0x12345678 on " + unknown + " from " + sender.Hex() + " with 1 ETH"},
+	}
+	for i, tc := range cases {
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{
+			PublicKey: pubKey,
+			TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+				ChainId:              "1",
+				Nonce:                uint64(i),
+				GasLimit:             100000,
+				MaxFeePerGas:         "30000000000",
+				MaxPriorityFeePerGas: "1000000000",
+				TxPayload:            tc.payload,
+			}),
+		})
+		if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Fatalf("%s: sign fail: %v %v", tc.name, err, resp)
+		}
+		intent := resp.Intent
+		if intent.Action != tc.action || intent.Amount != tc.amount || !strings.HasPrefix(intent.Summary, tc.summary) {
+			t.Errorf("%s: got action=%s amount=%q summary=%q", tc.name, intent.Action, intent.Amount, intent.Summary)
+		}
+		if tc.action != IntentNativeTransfer && tc.action != IntentContractCall && intent.Contract == "" {
+			t.Errorf("%s: token intent must carry the contract", tc.name)
+		}
+		// From 始终是签名者，代币调用的资产持有者单独记录在 Owner 中
+		wantOwner := ""
+		switch {
+		case tc.name == "transferFrom":
+			wantOwner = owner
+		case tc.action != IntentNativeTransfer && tc.action != IntentContractCall:
+			wantOwner = sender.Hex()
+		}
+		if intent.From != sender.Hex() || intent.Owner != wantOwner {
+			t.Errorf("%s: got from=%s owner=%s, want from=%s owner=%s", tc.name, intent.From, intent.Owner, sender.Hex(), wantOwner)
+		}
+		if intent.Network != "Ethereum" || intent.ChainId != "1" || intent.Nonce != uint64(i) || intent.MaxFee != "0.003 ETH" {
+			t.Errorf("%s: unexpected intent %v", tc.name, intent)
+		}
+
+		// 已签名交易 decode 得到的意图与签名时一致
+		decoded, _ := c.DecodeTransaction(context.Background(), &wallet.DecodeTransactionRequest{RawTx: resp.SignedTx})
+		if decoded.Intent.GetSummary() != intent.Summary {
+			t.Errorf("%s: decoded intent %q, want %q", tc.name, decoded.Intent.GetSummary(), intent.Summary)
+		}
+	}
+
+	// 未签名交易无法确定转出方
+	unsigned, _ := rlp.EncodeToBytes([]interface{}{uint64(5), big.NewInt(1000000000), uint64(21000), common.HexToAddress(testToAddress), big.NewInt(1e18), []byte{}, big.NewInt(1), uint(0), uint(0)})
+	decoded, _ := c.DecodeTransaction(context.Background(), &wallet.DecodeTransactionRequest{RawTx: hexutil.Encode(unsigned)})
+	want := "transfer 1 ETH from unknown sender to " + to + " on Ethereum, max fee 0.000021 ETH"
+	if decoded.Code != wallet.ReturnCode_SUCCESS || decoded.Intent.GetSummary() != want || decoded.Intent.GetFrom() != "" {
+		t.Errorf("unsigned intent: got %v, want %q", decoded, want)
+	}
+}
@@ -0,0 +1,41 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// personal_sign 消息的编码方式
+const (
+	MessageEncodingUtf8 = "utf8"
+	MessageEncodingHex  = "hex"
+)
+
+// DecodePersonalMessage 按 encoding 把请求中的消息还原成原始字节，encoding 为空时按 utf8 处理
+func DecodePersonalMessage(message string, encoding string) ([]byte, error) {
+	switch encoding {
+	case MessageEncodingUtf8, "":
+		if !utf8.ValidString(message) {
+			return nil, errors.New("message is not valid utf8")
+		}
+		return []byte(message), nil
+	case MessageEncodingHex:
+		msg, err := hexutil.Decode(message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex message: %w", err)
+		}
+		return msg, nil
+	default:
+		return nil, fmt.Errorf("unsupported message encoding: %s", encoding)
+	}
+}
+
+// PersonalMessageHash 计算 EIP-191 version 0x45 的 hash：keccak256("\x19Ethereum Signed Message:\n" + len(msg) + msg)
+func PersonalMessageHash(msg []byte) common.Hash {
+	return common.BytesToHash(accounts.TextHash(msg))
+}
@@ -0,0 +1,45 @@
+package ethereum
+
+import (
+	"context"
+	"testing"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestPersonalMessageHash(t *testing.T) {
+	// keccak256("\x19Ethereum Signed Message:\n11hello world")
+	want := "0xd9eba16ed0ecae432b71fe008c98cc872bb4cc214d3220a36f365326cf807d68"
+	if got := PersonalMessageHash([]byte("hello world")).Hex(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestSignPersonalMessage(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	for _, req := range []*wallet.SignPersonalMessageRequest{
+		{PublicKey: pubKey, Message: "hello world"},
+		{PublicKey: pubKey, Message: hexutil.Encode([]byte("hello world")), MessageEncoding: MessageEncodingHex},
+	} {
+		resp, err := c.SignPersonalMessage(context.Background(), req)
+		if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Fatalf("sign personal message fail: resp=%v err=%v", resp, err)
+		}
+		if resp.Digest != "0xd9eba16ed0ecae432b71fe008c98cc872bb4cc214d3220a36f365326cf807d68" {
+			t.Errorf("digest: got %s", resp.Digest)
+		}
+		sig := hexutil.MustDecode(resp.Signature)
+		if v := sig[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+			t.Errorf("v: got %d, want 27 or 28", v)
+		}
+	}
+
+	resp, _ := c.SignPersonalMessage(context.Background(), &wallet.SignPersonalMessageRequest{
+		PublicKey: pubKey, Message: "0xzz", MessageEncoding: MessageEncodingHex,
+	})
+	if resp.Code != wallet.ReturnCode_ERROR {
+		t.Errorf("invalid hex message must be rejected")
+	}
+}
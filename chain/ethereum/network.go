@@ -0,0 +1,182 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// PolicyError 表示请求被网络策略拒绝，Reason 会原样通过 reject_reason 返回给调用方
+type PolicyError struct {
+	Reason  wallet.RejectReason
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+func policyError(reason wallet.RejectReason, format string, args ...interface{}) error {
+	return &PolicyError{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// rejectReason 从错误链中取出拒绝原因，非策略错误返回 REJECT_REASON_NONE
+func rejectReason(err error) wallet.RejectReason {
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		return policyErr.Reason
+	}
+	return wallet.RejectReason_REJECT_REASON_NONE
+}
+
+// evmNetwork 是解析后的 config.EvmNetwork，适配器按它校验请求中的 chain_id、交易类型、fee / gas 上限与代币注册表
+type evmNetwork struct {
+	name                 string
+	chainID              *big.Int
+	symbol               string
+	maxFeePerGas         *big.Int
+	maxPriorityFeePerGas *big.Int
+	maxFeePerBlobGas     *big.Int
+	maxGasLimit          uint64
+	maxTotalFee          *big.Int
+	txTypes              map[string]bool
+	tokenRegistry        *tokenRegistry
+	simulationFork       string
+}
+
+func newEvmNetwork(conf *config.EvmNetwork) (*evmNetwork, error) {
+	if conf.Name == "" {
+		return nil, errors.New("evm network name is empty")
+	}
+	if conf.ChainId == 0 {
+		return nil, fmt.Errorf("evm network %s: chain_id is required", conf.Name)
+	}
+	network := &evmNetwork{
+		name:        conf.Name,
+		chainID:     new(big.Int).SetUint64(conf.ChainId),
+		symbol:      conf.Symbol,
+		maxGasLimit: conf.MaxGasLimit,
+	}
+	if conf.SimulationFork != "" {
+		if _, err := forkChainConfig(network.chainID, conf.SimulationFork); err != nil {
+			return nil, fmt.Errorf("evm network %s: %w", conf.Name, err)
+		}
+		network.simulationFork = conf.SimulationFork
+	}
+	ceilings := []struct {
+		name  string
+		value string
+		dst   **big.Int
+	}{
+		{"max_fee_per_gas", conf.MaxFeePerGas, &network.maxFeePerGas},
+		{"max_priority_fee_per_gas", conf.MaxPriorityFeePerGas, &network.maxPriorityFeePerGas},
+		{"max_fee_per_blob_gas", conf.MaxFeePerBlobGas, &network.maxFeePerBlobGas},
+		{"max_total_fee", conf.MaxTotalFee, &network.maxTotalFee},
+	}
+	for _, ceiling := range ceilings {
+		value, err := parseFeeCeiling(ceiling.value)
+		if err != nil {
+			return nil, fmt.Errorf("evm network %s: %s: %w", conf.Name, ceiling.name, err)
+		}
+		*ceiling.dst = value
+	}
+	tokenRegistry, err := newTokenRegistry(conf)
+	if err != nil {
+		return nil, fmt.Errorf("evm network %s: %w", conf.Name, err)
+	}
+	network.tokenRegistry = tokenRegistry
+	if len(conf.TxTypes) > 0 {
+		network.txTypes = make(map[string]bool, len(conf.TxTypes))
+		for _, txType := range conf.TxTypes {
+			switch txType {
+			case TxTypeLegacy, TxTypeAccessList, TxTypeDynamicFee, TxTypeBlob, TxTypeSetCode:
+				network.txTypes[txType] = true
+			default:
+				return nil, fmt.Errorf("evm network %s: unknown tx type %s", conf.Name, txType)
+			}
+		}
+	}
+	return network, nil
+}
+
+func parseFeeCeiling(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	return parseAmount(value)
+}
+
+// checkChainID 拒绝与网络 chain_id 不一致的请求，防止 Polygon 交易被当作主网交易签名（或反之）
+func (n *evmNetwork) checkChainID(chainID *big.Int) error {
+	if n == nil {
+		return nil
+	}
+	if chainID == nil || chainID.Cmp(n.chainID) != 0 {
+		return policyError(wallet.RejectReason_REJECT_REASON_CHAIN_ID_MISMATCH, "chain ID does not match network: %s expects %s, got %v", n.name, n.chainID, chainID)
+	}
+	return nil
+}
+
+// checkTx 在签名前校验交易；tip 不得超过 max fee 对所有网络生效，其余限制只在配置了网络时生效
+func (n *evmNetwork) checkTx(unsigned *unsignedTx) error {
+	tx := types.NewTx(unsigned.txData)
+	if tx.Type() != types.LegacyTxType && tx.Type() != types.AccessListTxType && tx.GasTipCap().Cmp(tx.GasFeeCap()) > 0 {
+		return policyError(wallet.RejectReason_REJECT_REASON_TIP_ABOVE_MAX_FEE, "max priority fee per gas %s exceeds max fee per gas %s", tx.GasTipCap(), tx.GasFeeCap())
+	}
+	if n == nil {
+		return nil
+	}
+	if err := n.checkChainID(unsigned.chainID); err != nil {
+		return err
+	}
+	if tx.To() != nil {
+		if err := n.checkTokenCall(*tx.To(), tx.Data(), unsigned.allowUnknownToken); err != nil {
+			return err
+		}
+	}
+	if n.txTypes != nil && !n.txTypes[unsigned.txType] {
+		return policyError(wallet.RejectReason_REJECT_REASON_TX_TYPE_NOT_ALLOWED, "tx type %s is not supported on %s", unsigned.txType, n.name)
+	}
+	if n.maxFeePerGas != nil && tx.GasFeeCap().Cmp(n.maxFeePerGas) > 0 {
+		return policyError(wallet.RejectReason_REJECT_REASON_MAX_FEE_TOO_HIGH, "max fee per gas %s exceeds %s ceiling %s", tx.GasFeeCap(), n.name, n.maxFeePerGas)
+	}
+	if n.maxPriorityFeePerGas != nil && tx.GasTipCap().Cmp(n.maxPriorityFeePerGas) > 0 {
+		return policyError(wallet.RejectReason_REJECT_REASON_PRIORITY_FEE_TOO_HIGH, "max priority fee per gas %s exceeds %s ceiling %s", tx.GasTipCap(), n.name, n.maxPriorityFeePerGas)
+	}
+	if n.maxFeePerBlobGas != nil && tx.Type() == types.BlobTxType && tx.BlobGasFeeCap().Cmp(n.maxFeePerBlobGas) > 0 {
+		return policyError(wallet.RejectReason_REJECT_REASON_BLOB_FEE_TOO_HIGH, "max fee per blob gas %s exceeds %s ceiling %s", tx.BlobGasFeeCap(), n.name, n.maxFeePerBlobGas)
+	}
+	if n.maxGasLimit > 0 && tx.Gas() > n.maxGasLimit {
+		return policyError(wallet.RejectReason_REJECT_REASON_GAS_LIMIT_TOO_HIGH, "gas limit %d exceeds %s ceiling %d", tx.Gas(), n.name, n.maxGasLimit)
+	}
+	if n.maxTotalFee != nil {
+		if totalFee := maxTxFee(tx); totalFee.Cmp(n.maxTotalFee) > 0 {
+			return policyError(wallet.RejectReason_REJECT_REASON_TOTAL_FEE_TOO_HIGH, "max total fee %s wei exceeds %s ceiling %s wei", totalFee, n.name, n.maxTotalFee)
+		}
+	}
+	return nil
+}
+
+// maxTxFee 是交易最多可能支付的手续费：gas_limit * max_fee_per_gas，blob 交易再加上 blob gas * max_fee_per_blob_gas
+func maxTxFee(tx *types.Transaction) *big.Int {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	if tx.Type() == types.BlobTxType {
+		blobGas := new(big.Int).SetUint64(uint64(len(tx.BlobHashes())) * params.BlobTxBlobGasPerBlob)
+		fee.Add(fee, blobGas.Mul(blobGas, tx.BlobGasFeeCap()))
+	}
+	return fee
+}
+
+// checkTypedDataDomain 校验 EIP-712 domain 中的 chainId；domain 未声明 chainId 时不做限制
+func (n *evmNetwork) checkTypedDataDomain(typedData *apitypes.TypedData) error {
+	if n == nil || typedData.Domain.ChainId == nil {
+		return nil
+	}
+	return n.checkChainID((*big.Int)(typedData.Domain.ChainId))
+}
@@ -0,0 +1,176 @@
+package ethereum
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+)
+
+func newTestNetworkAdaptor(t *testing.T, networkConf config.EvmNetwork) (*ChainAdaptor, string) {
+	t.Helper()
+	c, pubKey := newTestAdaptor(t)
+	network, err := newEvmNetwork(&networkConf)
+	if err != nil {
+		t.Fatalf("new evm network: %v", err)
+	}
+	c.network = network
+	return c, pubKey
+}
+
+func TestNetworkRejectsMismatchedTx(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{
+		Name:         "Polygon",
+		ChainId:      137,
+		Symbol:       "POL",
+		MaxFeePerGas: "1000000000000",
+		TxTypes:      []string{TxTypeLegacy, TxTypeDynamicFee},
+	})
+	dynamicFeeTx := func(chainId string, maxFee string) string {
+		return encodeTxBody(t, Eip1559DynamicFeeTx{
+			ChainId:              chainId,
+			GasLimit:             21000,
+			MaxFeePerGas:         maxFee,
+			MaxPriorityFeePerGas: "1000000000",
+			TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "1"},
+		})
+	}
+	cases := map[string]struct {
+		body string
+		want string
+	}{
+		"matching chain":   {dynamicFeeTx("137", "30000000000"), ""},
+		"mainnet chain id": {dynamicFeeTx("1", "30000000000"), "chain ID does not match network"},
+		"fee over ceiling": {dynamicFeeTx("137", "2000000000000"), "exceeds Polygon ceiling"},
+		"unsupported type": {encodeTxBody(t, Eip2930AccessListTx{
+			TxType:    TxTypeAccessList,
+			ChainId:   "137",
+			GasLimit:  21000,
+			GasPrice:  "30000000000",
+			TxPayload: TxPayload{ToAddress: testToAddress, Amount: "1"},
+		}), "not supported on Polygon"},
+	}
+	for name, tc := range cases {
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: tc.body})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if tc.want == "" {
+			if resp.Code != wallet.ReturnCode_SUCCESS {
+				t.Errorf("%s: got code %v (%s)", name, resp.Code, resp.Message)
+			}
+			continue
+		}
+		if resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, tc.want) {
+			t.Errorf("%s: got code=%v message=%q, want error containing %q", name, resp.Code, resp.Message, tc.want)
+		}
+		if resp.RejectReason == wallet.RejectReason_REJECT_REASON_NONE {
+			t.Errorf("%s: reject reason must be set", name)
+		}
+	}
+}
+
+func TestNetworkRejectsMismatchedTypedData(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{Name: "Base", ChainId: 8453, Symbol: "ETH"})
+
+	// mailTypedData 的 domain 声明的是主网 chainId 1
+	resp, err := c.SignTypedData(context.Background(), &wallet.SignTypedDataRequest{PublicKey: pubKey, TypedData: mailTypedData})
+	if err != nil || resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, "chain ID does not match network") {
+		t.Fatalf("typed data on wrong chain must be rejected: resp=%v err=%v", resp, err)
+	}
+
+	permit := &wallet.SignPermitRequest{
+		PublicKey:  pubKey,
+		PermitType: PermitTypePermit2Single,
+		ChainId:    "1",
+		Token:      testPermitToken,
+		Spender:    testPermitSpender,
+		Amount:     "1",
+		Nonce:      "0",
+		Deadline:   uint64(time.Now().Add(time.Hour).Unix()),
+	}
+	permitResp, err := c.SignPermit(context.Background(), permit)
+	if err != nil || permitResp.Code != wallet.ReturnCode_ERROR || !strings.Contains(permitResp.Message, "chain ID does not match network") {
+		t.Fatalf("permit on wrong chain must be rejected: resp=%v err=%v", permitResp, err)
+	}
+	permit.ChainId = "8453"
+	if permitResp, _ = c.SignPermit(context.Background(), permit); permitResp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("permit on matching chain fail: %s", permitResp.Message)
+	}
+}
+
+func TestNewEvmNetworkValidation(t *testing.T) {
+	cases := map[string]config.EvmNetwork{
+		"missing chain id": {Name: "Polygon"},
+		"bad fee ceiling":  {Name: "Polygon", ChainId: 137, MaxFeePerGas: "10 gwei"},
+		"unknown tx type":  {Name: "Polygon", ChainId: 137, TxTypes: []string{"eip9999"}},
+	}
+	for name, networkConf := range cases {
+		if _, err := newEvmNetwork(&networkConf); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestNetworkFeeLimits(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{
+		Name:                 "Ethereum",
+		ChainId:              1,
+		MaxFeePerGas:         "100000000000",
+		MaxPriorityFeePerGas: "5000000000",
+		MaxGasLimit:          1_000_000,
+		MaxTotalFee:          "10000000000000000", // 0.01 ETH
+	})
+	dynamicFeeTx := func(gasLimit uint64, maxFee string, tip string) *wallet.TransactionMessage {
+		return &wallet.TransactionMessage{PublicKey: pubKey, TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+			ChainId:              "1",
+			GasLimit:             gasLimit,
+			MaxFeePerGas:         maxFee,
+			MaxPriorityFeePerGas: tip,
+			TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "1"},
+		})}
+	}
+	cases := map[string]struct {
+		tx   *wallet.TransactionMessage
+		want wallet.RejectReason
+	}{
+		"within limits":     {dynamicFeeTx(21000, "30000000000", "1000000000"), wallet.RejectReason_REJECT_REASON_NONE},
+		"tip above max fee": {dynamicFeeTx(21000, "1000000000", "2000000000"), wallet.RejectReason_REJECT_REASON_TIP_ABOVE_MAX_FEE},
+		"max fee too high":  {dynamicFeeTx(21000, "200000000000", "1000000000"), wallet.RejectReason_REJECT_REASON_MAX_FEE_TOO_HIGH},
+		"tip too high":      {dynamicFeeTx(21000, "90000000000", "6000000000"), wallet.RejectReason_REJECT_REASON_PRIORITY_FEE_TOO_HIGH},
+		"gas limit":         {dynamicFeeTx(2_000_000, "1000000000", "1000000000"), wallet.RejectReason_REJECT_REASON_GAS_LIMIT_TOO_HIGH},
+		// 500000 * 30 gwei = 0.015 ETH
+		"total fee": {dynamicFeeTx(500_000, "30000000000", "1000000000"), wallet.RejectReason_REJECT_REASON_TOTAL_FEE_TOO_HIGH},
+	}
+	for name, tc := range cases {
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, TxBase64Body: tc.tx.TxBase64Body})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", name, err)
+		}
+		if resp.RejectReason != tc.want || (resp.Code == wallet.ReturnCode_SUCCESS) != (tc.want == wallet.RejectReason_REJECT_REASON_NONE) {
+			t.Errorf("%s: got code=%v reason=%v (%s), want reason %v", name, resp.Code, resp.RejectReason, resp.Message, tc.want)
+		}
+	}
+
+	// 批量签名同样执行限制，且每笔返回各自的拒绝原因
+	batch, err := c.BuildAndSignBatchTransaction(context.Background(), &wallet.BuildAndSignBatchTransactionRequest{
+		TxMsg: []*wallet.TransactionMessage{cases["within limits"].tx, cases["gas limit"].tx},
+	})
+	if err != nil || batch.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("batch fail: resp=%v err=%v", batch, err)
+	}
+	if batch.TxWithSign[0].Code != wallet.ReturnCode_SUCCESS || batch.TxWithSign[1].RejectReason != wallet.RejectReason_REJECT_REASON_GAS_LIMIT_TOO_HIGH {
+		t.Errorf("batch items: got %v", batch.TxWithSign)
+	}
+
+	// 未配置网络时仍拒绝 tip 高于 max fee 的交易
+	plain, plainPubKey := newTestAdaptor(t)
+	tx := dynamicFeeTx(21000, "1000000000", "2000000000")
+	resp, _ := plain.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{PublicKey: plainPubKey, TxBase64Body: tx.TxBase64Body})
+	if resp.RejectReason != wallet.RejectReason_REJECT_REASON_TIP_ABOVE_MAX_FEE {
+		t.Errorf("tip above max fee without network: got reason %v (%s)", resp.RejectReason, resp.Message)
+	}
+}
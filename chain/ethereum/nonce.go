@@ -0,0 +1,187 @@
+package ethereum
+
+import (
+	"math/big"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/Brant-Liang/wallet-sign/leveldb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// replacementPriceBump 是替换同 nonce 交易时 fee 至少提高的百分比，与 geth txpool 默认的 PriceBump 一致
+const replacementPriceBump = 10
+
+// senderNonceLocks 按 (chainId, sender) 保存一把锁，保证同一地址的 查询 nonce 记录 -> 签名 -> 写入记录 是原子的，
+// 否则两个上游 worker 并发提交同 nonce 的不同交易时都能通过检查；不同链、不同地址的签名互不阻塞。
+// 锁的数量以签过交易的地址数为上限，不做回收
+var senderNonceLocks sync.Map
+
+// lockSenderNonce 锁住 sender 在该链上的 nonce 记录，返回解锁函数
+func lockSenderNonce(chainID *big.Int, sender common.Address) func() {
+	return lockSenderNonceKeys([]string{senderNonceLockKey(chainID, sender)})
+}
+
+// lockSenderNonces 锁住一组交易涉及的全部 (chainId, sender)
+func lockSenderNonces(txs []*preparedTx) func() {
+	keys := make([]string, 0, len(txs))
+	for _, p := range txs {
+		keys = append(keys, senderNonceLockKey(p.unsigned.chainID, p.sender))
+	}
+	return lockSenderNonceKeys(keys)
+}
+
+// lockSenderNonceKeys 去重后按固定顺序加锁，避免两个 bundle 以相反顺序加锁时互相等待
+func lockSenderNonceKeys(keys []string) func() {
+	sort.Strings(keys)
+	keys = slices.Compact(keys)
+	locks := make([]*sync.Mutex, len(keys))
+	for i, key := range keys {
+		value, _ := senderNonceLocks.LoadOrStore(key, new(sync.Mutex))
+		locks[i] = value.(*sync.Mutex)
+		locks[i].Lock()
+	}
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+func senderNonceLockKey(chainID *big.Int, sender common.Address) string {
+	return chainID.String() + ":" + sender.Hex()
+}
+
+// signOptions 是交易体之外的签名选项
+type signOptions struct {
+	replacement       bool
+	replacementReason string
+	simulation        *wallet.Simulation // 非空时签名前先预执行
+}
+
+// auditLog 记录需要事后追溯的签名决策；每次调用取当前 root logger，保证 main 中 SetDefault 之后的配置生效
+func auditLog() log.Logger {
+	return log.Root().With("audit", true)
+}
+
+// checkSignedNonce 校验 sender 在该 nonce 上是否已签过其他交易：
+// 相同 digest 视为重签，直接放行；不同 digest 只有显式 replacement 且 fee 提高至少 10% 时放行
+func (c ChainAdaptor) checkSignedNonce(chainID *big.Int, sender common.Address, tx *types.Transaction, digest common.Hash, opts signOptions) (*leveldb.SignedNonce, error) {
+	previous, ok, err := c.db.GetSignedNonce(chainID.String(), sender.Hex(), tx.Nonce())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, c.checkNonceGap(chainID, sender, tx.Nonce())
+	}
+	if previous.Digest == digest.Hex() {
+		return previous, nil
+	}
+	if !opts.replacement {
+		return nil, policyError(wallet.RejectReason_REJECT_REASON_NONCE_CONFLICT, "nonce %d of %s was already signed for transaction %s, set replacement to replace it", tx.Nonce(), sender, previous.TxHash)
+	}
+	if opts.replacementReason == "" {
+		return nil, policyError(wallet.RejectReason_REJECT_REASON_NONCE_CONFLICT, "replacement_reason is required to replace nonce %d of %s", tx.Nonce(), sender)
+	}
+	fees := []struct {
+		name     string
+		previous string
+		current  *big.Int
+	}{
+		{"max fee per gas", previous.GasFeeCap, tx.GasFeeCap()},
+		{"max priority fee per gas", previous.GasTipCap, tx.GasTipCap()},
+		{"max fee per blob gas", previous.BlobGasFeeCap, tx.BlobGasFeeCap()},
+	}
+	for _, fee := range fees {
+		if fee.previous == "" {
+			continue
+		}
+		old, ok := new(big.Int).SetString(fee.previous, 10)
+		if !ok {
+			continue
+		}
+		// threshold = old * (100 + bump) / 100
+		threshold := new(big.Int).Mul(old, big.NewInt(100+replacementPriceBump))
+		threshold.Div(threshold, big.NewInt(100))
+		if fee.current == nil || fee.current.Cmp(threshold) < 0 {
+			return nil, policyError(wallet.RejectReason_REJECT_REASON_REPLACEMENT_UNDERPRICED, "replacement %s %v must be at least %s (%d%% above %s)", fee.name, fee.current, threshold, replacementPriceBump, old)
+		}
+	}
+	return previous, nil
+}
+
+// checkNonceGap 在新 nonce 跳过了已签最高 nonce 之后的 nonce 时记录审计日志：中间缺失的 nonce 不签出交易，
+// 该地址后续交易会一直卡在链上；这里只告警不拒绝，上游可能有意由其他签名路径补齐
+func (c ChainAdaptor) checkNonceGap(chainID *big.Int, sender common.Address, nonce uint64) error {
+	highest, ok, err := c.db.GetHighestNonce(chainID.String(), sender.Hex())
+	if err != nil || !ok || nonce <= highest.Nonce+1 {
+		return err
+	}
+	auditLog().Warn("signed nonce gap",
+		"network", c.networkName(),
+		"chainId", chainID,
+		"sender", sender,
+		"nonce", nonce,
+		"highestNonce", highest.Nonce,
+		"highestTxHash", highest.TxHash,
+	)
+	return nil
+}
+
+// recordSignedNonces 在同一个 batch 中写入已签交易的 nonce 记录，重签（digest 不变）的交易不重复写入；
+// 写入成功后替换交易在审计日志中留下原交易、新交易与替换原因
+func (c ChainAdaptor) recordSignedNonces(txs []*preparedTx) error {
+	entries := make([]leveldb.SignedNonceEntry, 0, len(txs))
+	replaced := make([]*preparedTx, 0)
+	for _, p := range txs {
+		if p.previous != nil && p.previous.Digest == p.digest.Hex() {
+			continue
+		}
+		record := &leveldb.SignedNonce{
+			Digest:    p.digest.Hex(),
+			TxHash:    p.txHash,
+			GasFeeCap: p.tx.GasFeeCap().String(),
+			GasTipCap: p.tx.GasTipCap().String(),
+			SignedAt:  time.Now().Unix(),
+		}
+		if p.tx.Type() == types.BlobTxType {
+			record.BlobGasFeeCap = p.tx.BlobGasFeeCap().String()
+		}
+		if p.previous != nil {
+			record.Replacements = p.previous.Replacements + 1
+			replaced = append(replaced, p)
+		}
+		entries = append(entries, leveldb.SignedNonceEntry{
+			ChainID: p.unsigned.chainID.String(),
+			Address: p.sender.Hex(),
+			Nonce:   p.tx.Nonce(),
+			Record:  record,
+		})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := c.db.StoreSignedNonces(entries); err != nil {
+		return err
+	}
+	for _, p := range replaced {
+		auditLog().Warn("replace signed nonce",
+			"network", c.networkName(),
+			"chainId", p.unsigned.chainID,
+			"sender", p.sender,
+			"nonce", p.tx.Nonce(),
+			"previousTxHash", p.previous.TxHash,
+			"previousMaxFeePerGas", p.previous.GasFeeCap,
+			"txHash", p.txHash,
+			"maxFeePerGas", p.tx.GasFeeCap(),
+			"replacements", p.previous.Replacements+1,
+			"reason", p.opts.replacementReason,
+		)
+	}
+	return nil
+}
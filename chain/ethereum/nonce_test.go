@@ -0,0 +1,125 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSignedNonceGuard(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	sender, _ := publicKeyToAddress(pubKey)
+	txBody := func(nonce uint64, amount string, maxFee string, tip string) string {
+		return encodeTxBody(t, Eip1559DynamicFeeTx{
+			ChainId:              "1",
+			Nonce:                nonce,
+			GasLimit:             21000,
+			MaxFeePerGas:         maxFee,
+			MaxPriorityFeePerGas: tip,
+			TxPayload:            TxPayload{ToAddress: testToAddress, Amount: amount},
+		})
+	}
+	sign := func(req *wallet.BuildAndSignTransactionRequest) *wallet.BuildAndSignTransactionResponse {
+		req.PublicKey = pubKey
+		resp, err := c.BuildAndSignTransaction(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return resp
+	}
+
+	first := sign(&wallet.BuildAndSignTransactionRequest{TxBase64Body: txBody(7, "1", "30000000000", "1000000000")})
+	if first.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("first sign fail: %s", first.Message)
+	}
+	// 相同交易重签是幂等的
+	if again := sign(&wallet.BuildAndSignTransactionRequest{TxBase64Body: txBody(7, "1", "30000000000", "1000000000")}); again.Code != wallet.ReturnCode_SUCCESS || again.TxHash != first.TxHash {
+		t.Fatalf("re-signing the same tx must succeed: %v", again)
+	}
+
+	conflicting := txBody(7, "2", "30000000000", "1000000000")
+	cases := map[string]struct {
+		req  *wallet.BuildAndSignTransactionRequest
+		want wallet.RejectReason
+	}{
+		"conflict":         {&wallet.BuildAndSignTransactionRequest{TxBase64Body: conflicting}, wallet.RejectReason_REJECT_REASON_NONCE_CONFLICT},
+		"missing reason":   {&wallet.BuildAndSignTransactionRequest{TxBase64Body: conflicting, Replacement: true}, wallet.RejectReason_REJECT_REASON_NONCE_CONFLICT},
+		"fee not bumped":   {&wallet.BuildAndSignTransactionRequest{TxBase64Body: conflicting, Replacement: true, ReplacementReason: "stuck"}, wallet.RejectReason_REJECT_REASON_REPLACEMENT_UNDERPRICED},
+		"tip bumped 5%":    {&wallet.BuildAndSignTransactionRequest{TxBase64Body: txBody(7, "2", "33000000000", "1050000000"), Replacement: true, ReplacementReason: "stuck"}, wallet.RejectReason_REJECT_REASON_REPLACEMENT_UNDERPRICED},
+		"other nonce free": {&wallet.BuildAndSignTransactionRequest{TxBase64Body: txBody(8, "2", "30000000000", "1000000000")}, wallet.RejectReason_REJECT_REASON_NONE},
+	}
+	for name, tc := range cases {
+		resp := sign(tc.req)
+		if resp.RejectReason != tc.want || (resp.Code == wallet.ReturnCode_SUCCESS) != (tc.want == wallet.RejectReason_REJECT_REASON_NONE) {
+			t.Errorf("%s: got code=%v reason=%v (%s), want reason %v", name, resp.Code, resp.RejectReason, resp.Message, tc.want)
+		}
+	}
+
+	// fee 提高 10% 的显式替换可以签名，并成为该 nonce 的新记录
+	replaced := sign(&wallet.BuildAndSignTransactionRequest{TxBase64Body: txBody(7, "2", "33000000000", "1100000000"), Replacement: true, ReplacementReason: "stuck in mempool"})
+	if replaced.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("replacement fail: %s", replaced.Message)
+	}
+	record, ok, err := c.db.GetSignedNonce("1", sender.Hex(), 7)
+	if err != nil || !ok || record.TxHash != replaced.TxHash || record.Replacements != 1 {
+		t.Fatalf("nonce record not updated: record=%+v ok=%v err=%v", record, ok, err)
+	}
+	if resp := sign(&wallet.BuildAndSignTransactionRequest{TxBase64Body: txBody(7, "1", "30000000000", "1000000000")}); resp.RejectReason != wallet.RejectReason_REJECT_REASON_NONCE_CONFLICT {
+		t.Errorf("original tx must be rejected after replacement: %v", resp)
+	}
+
+	// 批量内同 nonce 的两笔不同交易只能签成一笔
+	batch, err := c.BuildAndSignBatchTransaction(context.Background(), &wallet.BuildAndSignBatchTransactionRequest{TxMsg: []*wallet.TransactionMessage{
+		{PublicKey: pubKey, TxBase64Body: txBody(9, "1", "30000000000", "1000000000")},
+		{PublicKey: pubKey, TxBase64Body: txBody(9, "2", "30000000000", "1000000000")},
+	}})
+	if err != nil || batch.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("batch fail: resp=%v err=%v", batch, err)
+	}
+	if exactlyOne := (batch.TxWithSign[0].Code == wallet.ReturnCode_SUCCESS) != (batch.TxWithSign[1].Code == wallet.ReturnCode_SUCCESS); !exactlyOne {
+		t.Errorf("exactly one of the conflicting batch items must be signed: %v", batch.TxWithSign)
+	}
+	signed := batch.TxWithSign[0]
+	if signed.Code != wallet.ReturnCode_SUCCESS {
+		signed = batch.TxWithSign[1]
+	}
+	if highest, ok, err := c.db.GetHighestNonce("1", sender.Hex()); err != nil || !ok || highest.Nonce != 9 || highest.TxHash != signed.TxHash {
+		t.Errorf("highest nonce record: got %+v ok=%v err=%v, want nonce 9 tx %s", highest, ok, err, signed.TxHash)
+	}
+}
+
+func TestSenderNonceLock(t *testing.T) {
+	sender := common.HexToAddress(testToAddress)
+	unlock := lockSenderNonce(common.Big1, sender)
+
+	// 其他链或其他地址不被阻塞
+	done := make(chan struct{})
+	go func() {
+		lockSenderNonce(big.NewInt(137), sender)()
+		lockSenderNonce(common.Big1, common.HexToAddress(testSafeAddress))()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("locks of other senders must not block")
+	}
+
+	// 同一地址需等待前一次签名结束
+	locked := make(chan struct{})
+	go func() {
+		lockSenderNonce(common.Big1, sender)()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("same sender must wait for the lock")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-locked
+}
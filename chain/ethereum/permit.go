@@ -0,0 +1,397 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// permit_type 取值
+const (
+	PermitTypeEip2612       = "eip2612"
+	PermitTypePermit2Single = "permit2_single"
+	PermitTypePermit2Batch  = "permit2_batch"
+)
+
+// Permit2Address 是 Uniswap Permit2 在各 EVM 链上的规范部署地址
+var Permit2Address = common.HexToAddress("0x000000000022D473030F116dDEE9F6B43aC78BA3")
+
+var (
+	eip712DomainFields = []apitypes.Type{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	}
+	// Permit2 的 domain 没有 version 字段
+	permit2DomainFields = []apitypes.Type{
+		{Name: "name", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	}
+	permit2DetailsFields = []apitypes.Type{
+		{Name: "token", Type: "address"},
+		{Name: "amount", Type: "uint160"},
+		{Name: "expiration", Type: "uint48"},
+		{Name: "nonce", Type: "uint48"},
+	}
+)
+
+// Permit2Details 对应 Permit2 的 PermitDetails 结构
+type Permit2Details struct {
+	Token      common.Address
+	Amount     *big.Int
+	Expiration uint64
+	Nonce      *big.Int
+}
+
+// BuildEip2612Permit 构造 EIP-2612 Permit(owner,spender,value,nonce,deadline) 的 TypedData
+func BuildEip2612Permit(chainID *big.Int, token common.Address, tokenName string, tokenVersion string, owner common.Address, spender common.Address, value *big.Int, nonce *big.Int, deadline uint64) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			eip712DomainType: eip712DomainFields,
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              tokenName,
+			Version:           tokenVersion,
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: token.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner":    owner.Hex(),
+			"spender":  spender.Hex(),
+			"value":    value.String(),
+			"nonce":    nonce.String(),
+			"deadline": strconv.FormatUint(deadline, 10),
+		},
+	}
+}
+
+// BuildPermit2Single 构造 Permit2 的 PermitSingle(details,spender,sigDeadline) 的 TypedData
+func BuildPermit2Single(chainID *big.Int, permit2 common.Address, details Permit2Details, spender common.Address, sigDeadline uint64) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			eip712DomainType: permit2DomainFields,
+			"PermitDetails":  permit2DetailsFields,
+			"PermitSingle": {
+				{Name: "details", Type: "PermitDetails"},
+				{Name: "spender", Type: "address"},
+				{Name: "sigDeadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "PermitSingle",
+		Domain:      permit2Domain(chainID, permit2),
+		Message: apitypes.TypedDataMessage{
+			"details":     details.message(),
+			"spender":     spender.Hex(),
+			"sigDeadline": strconv.FormatUint(sigDeadline, 10),
+		},
+	}
+}
+
+// BuildPermit2Batch 构造 Permit2 的 PermitBatch(details[],spender,sigDeadline) 的 TypedData
+func BuildPermit2Batch(chainID *big.Int, permit2 common.Address, details []Permit2Details, spender common.Address, sigDeadline uint64) *apitypes.TypedData {
+	detailList := make([]interface{}, len(details))
+	for i := range details {
+		detailList[i] = details[i].message()
+	}
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			eip712DomainType: permit2DomainFields,
+			"PermitDetails":  permit2DetailsFields,
+			"PermitBatch": {
+				{Name: "details", Type: "PermitDetails[]"},
+				{Name: "spender", Type: "address"},
+				{Name: "sigDeadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "PermitBatch",
+		Domain:      permit2Domain(chainID, permit2),
+		Message: apitypes.TypedDataMessage{
+			"details":     detailList,
+			"spender":     spender.Hex(),
+			"sigDeadline": strconv.FormatUint(sigDeadline, 10),
+		},
+	}
+}
+
+func permit2Domain(chainID *big.Int, permit2 common.Address) apitypes.TypedDataDomain {
+	return apitypes.TypedDataDomain{
+		Name:              "Permit2",
+		ChainId:           (*math.HexOrDecimal256)(chainID),
+		VerifyingContract: permit2.Hex(),
+	}
+}
+
+func (d Permit2Details) message() apitypes.TypedDataMessage {
+	return apitypes.TypedDataMessage{
+		"token":      d.Token.Hex(),
+		"amount":     d.Amount.String(),
+		"expiration": strconv.FormatUint(d.Expiration, 10),
+		"nonce":      d.Nonce.String(),
+	}
+}
+
+// buildPermitTypedData 校验请求并按 permit_type 构造 TypedData；deadline 与 spender 需满足 policy
+func buildPermitTypedData(req *wallet.SignPermitRequest, owner common.Address, policy config.PermitPolicy, now time.Time) (*apitypes.TypedData, error) {
+	chainID, ok := new(big.Int).SetString(req.ChainId, 10)
+	if !ok || chainID.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid chain ID: %s", req.ChainId)
+	}
+	if !common.IsHexAddress(req.Spender) {
+		return nil, fmt.Errorf("invalid spender: %s", req.Spender)
+	}
+	spender := common.HexToAddress(req.Spender)
+	if err := checkPermitSpender(policy, spender); err != nil {
+		return nil, err
+	}
+	if err := checkPermitDeadline(policy, "deadline", req.Deadline, now); err != nil {
+		return nil, err
+	}
+
+	switch req.PermitType {
+	case PermitTypeEip2612:
+		if !common.IsHexAddress(req.Token) {
+			return nil, fmt.Errorf("invalid token: %s", req.Token)
+		}
+		if req.TokenName == "" {
+			return nil, errors.New("token_name is required for eip2612 permit")
+		}
+		value, err := parseBoundedUint("amount", req.Amount, 256)
+		if err != nil {
+			return nil, err
+		}
+		nonce, err := parseBoundedUint("nonce", req.Nonce, 256)
+		if err != nil {
+			return nil, err
+		}
+		tokenVersion := req.TokenVersion
+		if tokenVersion == "" {
+			tokenVersion = "1"
+		}
+		return BuildEip2612Permit(chainID, common.HexToAddress(req.Token), req.TokenName, tokenVersion, owner, spender, value, nonce, req.Deadline), nil
+	case PermitTypePermit2Single:
+		details, err := parsePermit2Details(policy, &wallet.PermitDetails{
+			Token:      req.Token,
+			Amount:     req.Amount,
+			Expiration: req.Expiration,
+			Nonce:      req.Nonce,
+		}, now)
+		if err != nil {
+			return nil, err
+		}
+		permit2, err := parsePermit2Address(req.Permit2Address)
+		if err != nil {
+			return nil, err
+		}
+		return BuildPermit2Single(chainID, permit2, details, spender, req.Deadline), nil
+	case PermitTypePermit2Batch:
+		if len(req.Details) == 0 {
+			return nil, errors.New("details is required for permit2 batch")
+		}
+		detailList := make([]Permit2Details, 0, len(req.Details))
+		for i, item := range req.Details {
+			details, err := parsePermit2Details(policy, item, now)
+			if err != nil {
+				return nil, fmt.Errorf("details %d: %w", i, err)
+			}
+			detailList = append(detailList, details)
+		}
+		permit2, err := parsePermit2Address(req.Permit2Address)
+		if err != nil {
+			return nil, err
+		}
+		return BuildPermit2Batch(chainID, permit2, detailList, spender, req.Deadline), nil
+	default:
+		return nil, fmt.Errorf("unsupported permit type: %s", req.PermitType)
+	}
+}
+
+func parsePermit2Details(policy config.PermitPolicy, item *wallet.PermitDetails, now time.Time) (Permit2Details, error) {
+	if !common.IsHexAddress(item.Token) {
+		return Permit2Details{}, fmt.Errorf("invalid token: %s", item.Token)
+	}
+	amount, err := parseBoundedUint("amount", item.Amount, 160)
+	if err != nil {
+		return Permit2Details{}, err
+	}
+	nonce, err := parseBoundedUint("nonce", item.Nonce, 48)
+	if err != nil {
+		return Permit2Details{}, err
+	}
+	if item.Expiration >= 1<<48 {
+		return Permit2Details{}, fmt.Errorf("expiration out of range: %d", item.Expiration)
+	}
+	// Permit2 中 expiration 为 0 表示授权只在当前区块有效
+	if item.Expiration != 0 {
+		if err := checkPermitDeadline(policy, "expiration", item.Expiration, now); err != nil {
+			return Permit2Details{}, err
+		}
+	}
+	return Permit2Details{
+		Token:      common.HexToAddress(item.Token),
+		Amount:     amount,
+		Expiration: item.Expiration,
+		Nonce:      nonce,
+	}, nil
+}
+
+func parsePermit2Address(address string) (common.Address, error) {
+	if address == "" {
+		return Permit2Address, nil
+	}
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("invalid permit2 address: %s", address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+// parseBoundedUint 解析十进制或 0x 十六进制的无符号整数并检查位宽，例如 Permit2 的 amount 是 uint160
+func parseBoundedUint(name string, value string, bits int) (*big.Int, error) {
+	n, ok := new(big.Int), false
+	if strings.HasPrefix(value, "0x") {
+		_, ok = n.SetString(value[2:], 16)
+	} else {
+		_, ok = n.SetString(value, 10)
+	}
+	if !ok || n.Sign() < 0 || n.BitLen() > bits {
+		return nil, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return n, nil
+}
+
+// checkPermitDeadline 要求时间戳在未来，且不超过 policy 允许的最长有效期
+func checkPermitDeadline(policy config.PermitPolicy, name string, deadline uint64, now time.Time) error {
+	nowUnix := uint64(now.Unix())
+	if deadline <= nowUnix {
+		return fmt.Errorf("%s %d is not in the future", name, deadline)
+	}
+	if policy.MaxDeadlineSeconds > 0 && deadline-nowUnix > policy.MaxDeadlineSeconds {
+		return fmt.Errorf("%s %d exceeds the max validity of %d seconds", name, deadline, policy.MaxDeadlineSeconds)
+	}
+	return nil
+}
+
+// checkPermitSpender 在配置了白名单时只允许授权给名单内的 spender
+func checkPermitSpender(policy config.PermitPolicy, spender common.Address) error {
+	if len(policy.AllowedSpenders) == 0 {
+		return nil
+	}
+	for _, allowed := range policy.AllowedSpenders {
+		if strings.EqualFold(allowed, spender.Hex()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("spender %s is not allowed by permit policy", spender.Hex())
+}
+
+// checkTypedDataPermit 对 SignTypedData 提交的 Permit / PermitSingle / PermitBatch 套用与 SignPermit 相同的 permit_policy，
+// 否则同一份授权可以换个接口绕过 spender 白名单与有效期限制；其他 primaryType 不做检查
+func checkTypedDataPermit(typedData *apitypes.TypedData, policy config.PermitPolicy, now time.Time) error {
+	message := typedData.Message
+	switch typedData.PrimaryType {
+	case "Permit":
+		// EIP-2612 的有效期字段是 deadline，DAI 风格的 permit 是 expiry
+		deadlineField := "deadline"
+		if _, ok := message[deadlineField]; !ok {
+			deadlineField = "expiry"
+		}
+		return checkTypedDataPermitFields(policy, message, deadlineField, now)
+	case "PermitSingle":
+		if err := checkTypedDataPermitFields(policy, message, "sigDeadline", now); err != nil {
+			return err
+		}
+		details, ok := message["details"].(map[string]interface{})
+		if !ok {
+			return errors.New("permit details is missing")
+		}
+		return checkTypedDataPermitExpiration(policy, details, now)
+	case "PermitBatch":
+		if err := checkTypedDataPermitFields(policy, message, "sigDeadline", now); err != nil {
+			return err
+		}
+		detailList, ok := message["details"].([]interface{})
+		if !ok {
+			return errors.New("permit details is missing")
+		}
+		for i, item := range detailList {
+			details, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("details %d: invalid permit details", i)
+			}
+			if err := checkTypedDataPermitExpiration(policy, details, now); err != nil {
+				return fmt.Errorf("details %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkTypedDataPermitFields(policy config.PermitPolicy, message apitypes.TypedDataMessage, deadlineField string, now time.Time) error {
+	spender, ok := message["spender"].(string)
+	if !ok || !common.IsHexAddress(spender) {
+		return fmt.Errorf("invalid spender: %v", message["spender"])
+	}
+	if err := checkPermitSpender(policy, common.HexToAddress(spender)); err != nil {
+		return err
+	}
+	deadline, err := typedDataTimestamp(deadlineField, message[deadlineField])
+	if err != nil {
+		return err
+	}
+	return checkPermitDeadline(policy, deadlineField, deadline, now)
+}
+
+func checkTypedDataPermitExpiration(policy config.PermitPolicy, details map[string]interface{}, now time.Time) error {
+	expiration, err := typedDataTimestamp("expiration", details["expiration"])
+	if err != nil {
+		return err
+	}
+	// 与 parsePermit2Details 一致，expiration 为 0 表示只在当前区块有效
+	if expiration == 0 {
+		return nil
+	}
+	return checkPermitDeadline(policy, "expiration", expiration, now)
+}
+
+// typedDataTimestamp 读取 TypedData message 中的时间戳，JSON 中可能是数字或十进制/十六进制字符串；
+// 超出 uint64 的值（例如 uint256 最大值）按 uint64 最大值处理，由有效期上限拒绝
+func typedDataTimestamp(name string, value interface{}) (uint64, error) {
+	var n *big.Int
+	switch v := value.(type) {
+	case string:
+		parsed, err := parseBoundedUint(name, v, 256)
+		if err != nil {
+			return 0, err
+		}
+		n = parsed
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return 0, fmt.Errorf("invalid %s: %v", name, v)
+		}
+		n = new(big.Int).SetUint64(uint64(v))
+	default:
+		return 0, fmt.Errorf("invalid %s: %v", name, value)
+	}
+	if !n.IsUint64() {
+		return ^uint64(0), nil
+	}
+	return n.Uint64(), nil
+}
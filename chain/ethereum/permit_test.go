@@ -0,0 +1,215 @@
+package ethereum
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const (
+	testPermitToken   = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	testPermitSpender = "0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD"
+)
+
+func TestSignPermit(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	owner, _ := publicKeyToAddress(pubKey)
+	deadline := uint64(time.Now().Add(time.Hour).Unix())
+
+	requests := map[string]*wallet.SignPermitRequest{
+		PermitTypeEip2612: {
+			PermitType:   PermitTypeEip2612,
+			TokenName:    "USD Coin",
+			TokenVersion: "2",
+			Token:        testPermitToken,
+			Amount:       "1000000",
+			Nonce:        "0",
+		},
+		PermitTypePermit2Single: {
+			PermitType: PermitTypePermit2Single,
+			Token:      testPermitToken,
+			Amount:     "1000000",
+			Nonce:      "3",
+			Expiration: deadline,
+		},
+		PermitTypePermit2Batch: {
+			PermitType: PermitTypePermit2Batch,
+			Details: []*wallet.PermitDetails{
+				{Token: testPermitToken, Amount: "1", Nonce: "0", Expiration: deadline},
+				{Token: testToAddress, Amount: "2", Nonce: "1", Expiration: 0},
+			},
+		},
+	}
+	for permitType, req := range requests {
+		req.PublicKey = pubKey
+		req.ChainId = "1"
+		req.Spender = testPermitSpender
+		req.Deadline = deadline
+		resp, err := c.SignPermit(context.Background(), req)
+		if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Fatalf("%s: sign permit fail: resp=%v err=%v", permitType, resp, err)
+		}
+
+		// 返回的 typed_data 重新计算出的 digest 必须与签名的 digest 一致
+		var typedData apitypes.TypedData
+		if err := json.Unmarshal([]byte(resp.TypedData), &typedData); err != nil {
+			t.Fatalf("%s: unmarshal typed data: %v", permitType, err)
+		}
+		typedDataHash, err := HashTypedData(&typedData)
+		if err != nil {
+			t.Fatalf("%s: hash typed data: %v", permitType, err)
+		}
+		if typedDataHash.Digest.Hex() != resp.Digest {
+			t.Fatalf("%s: digest mismatch: got %s, want %s", permitType, resp.Digest, typedDataHash.Digest.Hex())
+		}
+
+		sig := hexutil.MustDecode(resp.Signature)
+		if uint32(sig[crypto.RecoveryIDOffset]) != resp.V || resp.R != hexutil.Encode(sig[:32]) || resp.S != hexutil.Encode(sig[32:64]) {
+			t.Fatalf("%s: v/r/s do not match signature", permitType)
+		}
+		sig[crypto.RecoveryIDOffset] -= 27
+		recovered, err := crypto.SigToPub(hexutil.MustDecode(resp.Digest), sig)
+		if err != nil || crypto.PubkeyToAddress(*recovered) != owner {
+			t.Fatalf("%s: recovered signer mismatch: err=%v", permitType, err)
+		}
+	}
+}
+
+func TestEip2612PermitDomain(t *testing.T) {
+	owner := common.HexToAddress(testToAddress)
+	typedData := BuildEip2612Permit(common.Big1, common.HexToAddress(testPermitToken), "USD Coin", "2", owner, common.HexToAddress(testPermitSpender), common.Big1, common.Big0, 1)
+	typedDataHash, err := HashTypedData(typedData)
+	if err != nil {
+		t.Fatalf("hash permit: %v", err)
+	}
+	// USDC 在主网上的 DOMAIN_SEPARATOR()
+	if got := typedDataHash.DomainSeparator.Hex(); got != "0x06c37168a7db5138defc7866392bb87a741f9b3d104deb5094588ce041cae335" {
+		t.Errorf("domain separator: got %s", got)
+	}
+}
+
+func TestSignPermitPolicy(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	c.conf = &config.Config{PermitPolicy: config.PermitPolicy{
+		MaxDeadlineSeconds: 3600,
+		AllowedSpenders:    []string{strings.ToLower(testPermitSpender)},
+	}}
+	now := time.Now()
+	base := func() *wallet.SignPermitRequest {
+		return &wallet.SignPermitRequest{
+			PublicKey:  pubKey,
+			PermitType: PermitTypeEip2612,
+			ChainId:    "1",
+			Token:      testPermitToken,
+			TokenName:  "USD Coin",
+			Spender:    testPermitSpender,
+			Amount:     "1",
+			Nonce:      "0",
+			Deadline:   uint64(now.Add(time.Minute).Unix()),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *wallet.SignPermitRequest)
+		want   string
+	}{
+		{"expired deadline", func(req *wallet.SignPermitRequest) { req.Deadline = uint64(now.Add(-time.Minute).Unix()) }, "not in the future"},
+		{"deadline too far", func(req *wallet.SignPermitRequest) { req.Deadline = uint64(now.Add(2 * time.Hour).Unix()) }, "exceeds the max validity"},
+		{"spender not allowed", func(req *wallet.SignPermitRequest) { req.Spender = testToAddress }, "not allowed"},
+		{"permit2 amount overflow", func(req *wallet.SignPermitRequest) {
+			req.PermitType = PermitTypePermit2Single
+			req.Amount = "1461501637330902918203684832716283019655932542976" // 2^160
+		}, "invalid amount"},
+		{"unknown permit type", func(req *wallet.SignPermitRequest) { req.PermitType = "dai" }, "unsupported permit type"},
+	}
+	for _, tt := range tests {
+		req := base()
+		tt.modify(req)
+		resp, err := c.SignPermit(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, tt.want) {
+			t.Errorf("%s: got code=%v message=%q, want error containing %q", tt.name, resp.Code, resp.Message, tt.want)
+		}
+	}
+
+	resp, _ := c.SignPermit(context.Background(), base())
+	if resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign permit within policy fail: %s", resp.Message)
+	}
+}
+
+func TestSignTypedDataPermitPolicy(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	c.conf = &config.Config{PermitPolicy: config.PermitPolicy{
+		MaxDeadlineSeconds: 3600,
+		AllowedSpenders:    []string{testPermitSpender},
+	}}
+	owner, _ := publicKeyToAddress(pubKey)
+	chainID := common.Big1
+	token := common.HexToAddress(testPermitToken)
+	spender := common.HexToAddress(testPermitSpender)
+	now := time.Now()
+	valid := uint64(now.Add(time.Minute).Unix())
+	tooFar := uint64(now.Add(2 * time.Hour).Unix())
+	details := func(expiration uint64) Permit2Details {
+		return Permit2Details{Token: token, Amount: common.Big1, Expiration: expiration, Nonce: common.Big0}
+	}
+	daiPermit := BuildEip2612Permit(chainID, token, "Dai Stablecoin", "1", owner, spender, common.Big1, common.Big0, valid)
+	delete(daiPermit.Message, "deadline")
+	daiPermit.Message["expiry"] = "0"
+
+	tests := []struct {
+		name      string
+		typedData *apitypes.TypedData
+		want      string
+	}{
+		{"eip2612 spender not allowed", BuildEip2612Permit(chainID, token, "USD Coin", "2", owner, common.HexToAddress(testToAddress), common.Big1, common.Big0, valid), "not allowed"},
+		{"eip2612 deadline too far", BuildEip2612Permit(chainID, token, "USD Coin", "2", owner, spender, common.Big1, common.Big0, tooFar), "exceeds the max validity"},
+		{"dai permit without expiry", daiPermit, "not in the future"},
+		{"permit2 single sig deadline", BuildPermit2Single(chainID, Permit2Address, details(valid), spender, tooFar), "exceeds the max validity"},
+		{"permit2 single expiration", BuildPermit2Single(chainID, Permit2Address, details(tooFar), spender, valid), "expiration"},
+		{"permit2 batch expiration", BuildPermit2Batch(chainID, Permit2Address, []Permit2Details{details(valid), details(tooFar)}, spender, valid), "details 1"},
+	}
+	for _, tt := range tests {
+		// 经过 JSON 往返，与 SignTypedData 收到的请求一致
+		typedDataJson, _ := json.Marshal(tt.typedData)
+		resp, err := c.SignTypedData(context.Background(), &wallet.SignTypedDataRequest{PublicKey: pubKey, TypedData: string(typedDataJson)})
+		if err != nil || resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, tt.want) {
+			t.Errorf("%s: got %v %v, want error containing %q", tt.name, resp, err, tt.want)
+		}
+	}
+
+	allowed := []*apitypes.TypedData{
+		BuildEip2612Permit(chainID, token, "USD Coin", "2", owner, spender, common.Big1, common.Big0, valid),
+		BuildPermit2Batch(chainID, Permit2Address, []Permit2Details{details(0), details(valid)}, spender, valid),
+	}
+	for _, typedData := range allowed {
+		typedDataJson, _ := json.Marshal(typedData)
+		resp, _ := c.SignTypedData(context.Background(), &wallet.SignTypedDataRequest{PublicKey: pubKey, TypedData: string(typedDataJson)})
+		if resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Errorf("%s within policy: %s", typedData.PrimaryType, resp.Message)
+		}
+	}
+
+	// JSON 数字形式的 deadline 同样受限
+	typedDataJson, _ := json.Marshal(BuildEip2612Permit(chainID, token, "USD Coin", "2", owner, spender, common.Big1, common.Big0, tooFar))
+	deadline := strconv.FormatUint(tooFar, 10)
+	numeric := strings.Replace(string(typedDataJson), `"deadline":"`+deadline+`"`, `"deadline":`+deadline, 1)
+	resp, _ := c.SignTypedData(context.Background(), &wallet.SignTypedDataRequest{PublicKey: pubKey, TypedData: numeric})
+	if resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, "exceeds the max validity") {
+		t.Errorf("numeric deadline: got %v", resp)
+	}
+}
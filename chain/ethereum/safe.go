@@ -0,0 +1,187 @@
+package ethereum
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// signature_type 取值
+const (
+	SafeSignatureEip712  = "eip712"
+	SafeSignatureEthSign = "eth_sign"
+)
+
+// Safe 用 v > 30 区分 eth_sign 签名，合约会先对 safeTxHash 加 EIP-191 前缀再 ecrecover
+const safeEthSignVOffset = 4
+
+// SafeTransaction 对应 Safe 合约 execTransaction 的参数
+type SafeTransaction struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      uint8
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+// SafeSignature 是单个 owner 的签名，v 已按 Safe 的规则调整
+type SafeSignature struct {
+	Owner     common.Address
+	Signature []byte
+}
+
+// BuildSafeTxTypedData 构造 Safe v1.3.0 及以后版本的 SafeTx TypedData，domain 只有 chainId 与 verifyingContract
+func BuildSafeTxTypedData(chainID *big.Int, safe common.Address, safeTx *SafeTransaction) *apitypes.TypedData {
+	return &apitypes.TypedData{
+		Types: apitypes.Types{
+			eip712DomainType: {
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"SafeTx": {
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain: apitypes.TypedDataDomain{
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: safe.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"to":             safeTx.To.Hex(),
+			"value":          safeTx.Value.String(),
+			"data":           hexutil.Encode(safeTx.Data),
+			"operation":      strconv.FormatUint(uint64(safeTx.Operation), 10),
+			"safeTxGas":      safeTx.SafeTxGas.String(),
+			"baseGas":        safeTx.BaseGas.String(),
+			"gasPrice":       safeTx.GasPrice.String(),
+			"gasToken":       safeTx.GasToken.Hex(),
+			"refundReceiver": safeTx.RefundReceiver.Hex(),
+			"nonce":          safeTx.Nonce.String(),
+		},
+	}
+}
+
+// SafeSigningDigest 返回实际被签名的 hash：eip712 直接签 safeTxHash，eth_sign 签 EIP-191 前缀后的 safeTxHash
+func SafeSigningDigest(safeTxHash common.Hash, signatureType string) (common.Hash, error) {
+	switch signatureType {
+	case SafeSignatureEip712, "":
+		return safeTxHash, nil
+	case SafeSignatureEthSign:
+		return PersonalMessageHash(safeTxHash[:]), nil
+	default:
+		return common.Hash{}, fmt.Errorf("unsupported safe signature type: %s", signatureType)
+	}
+}
+
+// ToSafeSignature 把 0/1 的 recovery id 转成 Safe 需要的 v：eip712 为 27/28，eth_sign 为 31/32
+func ToSafeSignature(sig []byte, signatureType string) []byte {
+	safeSig := toWalletSignature(sig)
+	if signatureType == SafeSignatureEthSign {
+		safeSig[crypto.RecoveryIDOffset] += safeEthSignVOffset
+	}
+	return safeSig
+}
+
+// PackSafeSignatures 按 owner 地址升序拼接签名，这是 Safe checkSignatures 要求的顺序
+func PackSafeSignatures(signatures []SafeSignature) ([]byte, error) {
+	sorted := make([]SafeSignature, len(signatures))
+	copy(sorted, signatures)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Owner[:], sorted[j].Owner[:]) < 0
+	})
+	packed := make([]byte, 0, len(sorted)*crypto.SignatureLength)
+	for i, signature := range sorted {
+		if i > 0 && sorted[i-1].Owner == signature.Owner {
+			return nil, fmt.Errorf("duplicate safe owner: %s", signature.Owner.Hex())
+		}
+		packed = append(packed, signature.Signature...)
+	}
+	return packed, nil
+}
+
+func parseSafeTx(req *wallet.SafeTx) (*SafeTransaction, error) {
+	if req == nil {
+		return nil, errors.New("safe_tx is required")
+	}
+	if !common.IsHexAddress(req.To) {
+		return nil, fmt.Errorf("invalid to address: %s", req.To)
+	}
+	if req.Operation > 1 {
+		return nil, fmt.Errorf("invalid operation: %d", req.Operation)
+	}
+	if req.Nonce == "" {
+		return nil, errors.New("safe nonce is required")
+	}
+	safeTx := &SafeTransaction{
+		To:        common.HexToAddress(req.To),
+		Operation: uint8(req.Operation),
+	}
+	var err error
+	uints := []struct {
+		name  string
+		value string
+		dst   **big.Int
+	}{
+		{"value", req.Value, &safeTx.Value},
+		{"safe_tx_gas", req.SafeTxGas, &safeTx.SafeTxGas},
+		{"base_gas", req.BaseGas, &safeTx.BaseGas},
+		{"gas_price", req.GasPrice, &safeTx.GasPrice},
+		{"nonce", req.Nonce, &safeTx.Nonce},
+	}
+	for _, field := range uints {
+		value := field.value
+		if value == "" {
+			value = "0"
+		}
+		if *field.dst, err = parseBoundedUint(field.name, value, 256); err != nil {
+			return nil, err
+		}
+	}
+	if req.Data != "" {
+		if safeTx.Data, err = hexutil.Decode(req.Data); err != nil {
+			return nil, fmt.Errorf("invalid data: %w", err)
+		}
+	}
+	if safeTx.GasToken, err = parseOptionalAddress("gas_token", req.GasToken); err != nil {
+		return nil, err
+	}
+	if safeTx.RefundReceiver, err = parseOptionalAddress("refund_receiver", req.RefundReceiver); err != nil {
+		return nil, err
+	}
+	return safeTx, nil
+}
+
+func parseOptionalAddress(name string, address string) (common.Address, error) {
+	if address == "" {
+		return common.Address{}, nil
+	}
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("invalid %s: %s", name, address)
+	}
+	return common.HexToAddress(address), nil
+}
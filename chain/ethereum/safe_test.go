@@ -0,0 +1,138 @@
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/Brant-Liang/wallet-sign/leveldb"
+	"github.com/Brant-Liang/wallet-sign/ssm"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testSafeAddress = "0x1c511D88ba898b4D9cd9113D13B9c360a02Fcea1"
+
+// safeTxHashByContract 按 Safe 合约 getTransactionHash 的写法计算 safeTxHash，用来交叉验证 TypedData 的结果
+func safeTxHashByContract(chainID *big.Int, safe common.Address, tx *SafeTransaction) common.Hash {
+	domainTypeHash := common.HexToHash("0x47e79534a245952e8b16893a336b85a3d9ea9fa8c573f3d803afb92a79469218")
+	safeTxTypeHash := common.HexToHash("0xbb8310d486368db6bd6f849402fdd73ad53d316b5a4b2644ad6efe0f941286d8")
+	word := func(n *big.Int) []byte { return common.BigToHash(n).Bytes() }
+	address := func(a common.Address) []byte { return common.BytesToHash(a[:]).Bytes() }
+
+	domainSeparator := crypto.Keccak256(domainTypeHash[:], word(chainID), address(safe))
+	structHash := crypto.Keccak256(
+		safeTxTypeHash[:],
+		address(tx.To),
+		word(tx.Value),
+		crypto.Keccak256(tx.Data),
+		word(big.NewInt(int64(tx.Operation))),
+		word(tx.SafeTxGas),
+		word(tx.BaseGas),
+		word(tx.GasPrice),
+		address(tx.GasToken),
+		address(tx.RefundReceiver),
+		word(tx.Nonce),
+	)
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator, structHash)
+}
+
+func TestSignSafeTransaction(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	otherPubKey := storeTestKey(t, c.db)
+	erc20 := BuildErc20Data(common.HexToAddress(testToAddress), big.NewInt(1000))
+	req := &wallet.SignSafeTransactionRequest{
+		PublicKeys:  []string{pubKey, otherPubKey},
+		SafeAddress: testSafeAddress,
+		ChainId:     "1",
+		SafeTx: &wallet.SafeTx{
+			To:    testPermitToken,
+			Data:  hexutil.Encode(erc20),
+			Nonce: "42",
+		},
+	}
+	safeTx, err := parseSafeTx(req.SafeTx)
+	if err != nil {
+		t.Fatalf("parse safe tx: %v", err)
+	}
+	wantHash := safeTxHashByContract(big.NewInt(1), common.HexToAddress(testSafeAddress), safeTx)
+
+	for _, signatureType := range []string{SafeSignatureEip712, SafeSignatureEthSign} {
+		req.SignatureType = signatureType
+		resp, err := c.SignSafeTransaction(context.Background(), req)
+		if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Fatalf("%s: sign safe tx fail: resp=%v err=%v", signatureType, resp, err)
+		}
+		if resp.SafeTxHash != wantHash.Hex() {
+			t.Fatalf("%s: safe tx hash: got %s, want %s", signatureType, resp.SafeTxHash, wantHash.Hex())
+		}
+		digest, _ := SafeSigningDigest(wantHash, signatureType)
+
+		// 模拟 Safe checkSignatures：按 65 字节切分，owner 必须严格递增且能由签名恢复
+		packed := hexutil.MustDecode(resp.Signatures)
+		if len(packed) != 2*crypto.SignatureLength {
+			t.Fatalf("%s: packed signatures length: got %d", signatureType, len(packed))
+		}
+		var lastOwner common.Address
+		for i := 0; i < len(packed); i += crypto.SignatureLength {
+			sig := append([]byte(nil), packed[i:i+crypto.SignatureLength]...)
+			v := sig[crypto.RecoveryIDOffset]
+			if signatureType == SafeSignatureEthSign && v != 31 && v != 32 || signatureType == SafeSignatureEip712 && v != 27 && v != 28 {
+				t.Fatalf("%s: unexpected v %d", signatureType, v)
+			}
+			sig[crypto.RecoveryIDOffset] = (v - 27) % 4
+			pub, err := crypto.SigToPub(digest[:], sig)
+			if err != nil {
+				t.Fatalf("%s: recover owner: %v", signatureType, err)
+			}
+			owner := crypto.PubkeyToAddress(*pub)
+			if bytes.Compare(owner[:], lastOwner[:]) <= 0 {
+				t.Fatalf("%s: owners are not sorted ascending", signatureType)
+			}
+			lastOwner = owner
+		}
+	}
+}
+
+func TestSignSafeTransactionRejects(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	base := func() *wallet.SignSafeTransactionRequest {
+		return &wallet.SignSafeTransactionRequest{
+			PublicKeys:  []string{pubKey},
+			SafeAddress: testSafeAddress,
+			ChainId:     "1",
+			SafeTx:      &wallet.SafeTx{To: testToAddress, Value: "1", Nonce: "0"},
+		}
+	}
+	cases := map[string]func(req *wallet.SignSafeTransactionRequest){
+		"duplicate owner":    func(req *wallet.SignSafeTransactionRequest) { req.PublicKeys = []string{pubKey, pubKey} },
+		"missing nonce":      func(req *wallet.SignSafeTransactionRequest) { req.SafeTx.Nonce = "" },
+		"bad operation":      func(req *wallet.SignSafeTransactionRequest) { req.SafeTx.Operation = 2 },
+		"bad signature type": func(req *wallet.SignSafeTransactionRequest) { req.SignatureType = "approved_hash" },
+		"no owners":          func(req *wallet.SignSafeTransactionRequest) { req.PublicKeys = nil },
+	}
+	for name, modify := range cases {
+		req := base()
+		modify(req)
+		resp, err := c.SignSafeTransaction(context.Background(), req)
+		if err != nil || resp.Code != wallet.ReturnCode_ERROR || resp.Message == "" {
+			t.Errorf("%s: expected error response, got resp=%v err=%v", name, resp, err)
+		}
+	}
+}
+
+func storeTestKey(t *testing.T, db *leveldb.Keys) string {
+	t.Helper()
+	privKey, pubKey, _, err := ssm.NewEcdsaSigner().CreateKeyPair()
+	if err != nil {
+		t.Fatalf("create key pair: %v", err)
+	}
+	address, _ := publicKeyToAddress(pubKey)
+	if ok := db.StoreKeys([]leveldb.Key{{PrivateKey: privKey, Pubkey: pubKey, Address: address.Hex()}}); !ok {
+		t.Fatal("store keys fail")
+	}
+	return pubKey
+}
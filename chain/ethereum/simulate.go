@@ -0,0 +1,386 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// defaultSimulationBlockNumber 足够新，保证主网已激活的升级（Cancun、Prague）在预执行中生效
+const defaultSimulationBlockNumber = 23_000_000
+
+// erc20TransferTopic 是 Transfer(address,address,uint256) 的事件签名；ERC-721 同名事件有 4 个 topic，按 topic 数区分
+var erc20TransferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// simulation_fork 取值，按激活顺序排列
+const (
+	SimulationForkLondon   = "london"
+	SimulationForkShanghai = "shanghai"
+	SimulationForkCancun   = "cancun"
+	SimulationForkPrague   = "prague"
+)
+
+// knownChainConfigs 是 geth 内置了完整升级时间表的网络，预执行按区块高度与时间戳判定生效的升级
+var knownChainConfigs = map[uint64]*params.ChainConfig{
+	1:        params.MainnetChainConfig,
+	11155111: params.SepoliaChainConfig,
+	17000:    params.HoleskyChainConfig,
+	560048:   params.HoodiChainConfig,
+}
+
+// simulationChainConfig 返回预执行使用的链配置：配置了 simulation_fork 的网络从创世起激活到该升级为止的全部 EIP；
+// 未配置时只支持 geth 内置升级时间表的以太坊主网与测试网，L2、侧链的升级节奏与主网不同，拒绝预执行
+func (n *evmNetwork) simulationChainConfig(chainID *big.Int) (*params.ChainConfig, error) {
+	if n != nil && n.simulationFork != "" {
+		return forkChainConfig(chainID, n.simulationFork)
+	}
+	if chainID.IsUint64() {
+		if chainConfig, ok := knownChainConfigs[chainID.Uint64()]; ok {
+			return chainConfig, nil
+		}
+	}
+	return nil, fmt.Errorf("simulation is not supported on chain %s, configure simulation_fork for the network", chainID)
+}
+
+// forkChainConfig 构造从创世区块起激活到 fork 为止全部升级的链配置
+func forkChainConfig(chainID *big.Int, fork string) (*params.ChainConfig, error) {
+	genesis, zeroTime := new(big.Int), uint64(0)
+	chainConfig := &params.ChainConfig{
+		ChainID:                 chainID,
+		HomesteadBlock:          genesis,
+		EIP150Block:             genesis,
+		EIP155Block:             genesis,
+		EIP158Block:             genesis,
+		ByzantiumBlock:          genesis,
+		ConstantinopleBlock:     genesis,
+		PetersburgBlock:         genesis,
+		IstanbulBlock:           genesis,
+		MuirGlacierBlock:        genesis,
+		BerlinBlock:             genesis,
+		LondonBlock:             genesis,
+		ArrowGlacierBlock:       genesis,
+		GrayGlacierBlock:        genesis,
+		MergeNetsplitBlock:      genesis,
+		TerminalTotalDifficulty: genesis,
+		BlobScheduleConfig: &params.BlobScheduleConfig{
+			Cancun: params.DefaultCancunBlobConfig,
+			Prague: params.DefaultPragueBlobConfig,
+		},
+	}
+	switch fork {
+	case SimulationForkPrague:
+		chainConfig.PragueTime = &zeroTime
+		fallthrough
+	case SimulationForkCancun:
+		chainConfig.CancunTime = &zeroTime
+		fallthrough
+	case SimulationForkShanghai:
+		chainConfig.ShanghaiTime = &zeroTime
+		fallthrough
+	case SimulationForkLondon:
+		return chainConfig, nil
+	default:
+		return nil, fmt.Errorf("unknown simulation fork: %s", fork)
+	}
+}
+
+// simulateTx 在内存 StateDB 上用 core/vm 执行交易，状态只来自 state_overrides，不访问任何节点；
+// 预执行不扣手续费，只关心执行结果与代币流向。chainConfig 决定生效的升级与预编译合约，
+// L2 特有的预编译与系统合约（如 Arbitrum 的 ArbSys、OP Stack 的 L1Block）不在模拟范围内
+func simulateTx(chainConfig *params.ChainConfig, sender common.Address, tx *types.Transaction, authorities []common.Address, sim *wallet.Simulation, now time.Time) (*wallet.SimulationResult, error) {
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(triedb.NewDatabase(rawdb.NewMemoryDatabase(), nil), nil))
+	if err != nil {
+		return nil, err
+	}
+	if err := applyStateOverrides(statedb, sim.StateOverrides); err != nil {
+		return nil, err
+	}
+	statedb.SetNonce(sender, tx.Nonce(), tracing.NonceChangeUnspecified)
+
+	blockNumber := new(big.Int).SetUint64(sim.BlockNumber)
+	if sim.BlockNumber == 0 {
+		blockNumber.SetUint64(defaultSimulationBlockNumber)
+	}
+	blockTime := sim.BlockTimestamp
+	if blockTime == 0 {
+		blockTime = uint64(now.Unix())
+	}
+	baseFee := new(big.Int)
+	if sim.BaseFee != "" {
+		if baseFee, err = parseBoundedUint("base_fee", sim.BaseFee, 256); err != nil {
+			return nil, err
+		}
+	}
+	if tx.GasFeeCap().Cmp(baseFee) < 0 {
+		return nil, fmt.Errorf("max fee per gas %s is below simulation base fee %s", tx.GasFeeCap(), baseFee)
+	}
+	rules := chainConfig.Rules(blockNumber, true, blockTime)
+
+	intrinsic := intrinsicGas(tx, rules)
+	if tx.Gas() < intrinsic {
+		return nil, policyError(wallet.RejectReason_REJECT_REASON_SIMULATION_REVERTED, "gas limit %d is below intrinsic gas %d", tx.Gas(), intrinsic)
+	}
+
+	random := common.Hash{}
+	blockCtx := vm.BlockContext{
+		CanTransfer: canTransfer,
+		Transfer:    transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		GasLimit:    tx.Gas(),
+		BlockNumber: blockNumber,
+		Time:        blockTime,
+		Difficulty:  new(big.Int),
+		BaseFee:     baseFee,
+		BlobBaseFee: big.NewInt(1),
+		Random:      &random,
+	}
+	// effective gas price = min(max_fee_per_gas, base_fee + tip)
+	gasPrice := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if gasPrice.Cmp(tx.GasFeeCap()) > 0 {
+		gasPrice = tx.GasFeeCap()
+	}
+	evm := vm.NewEVM(blockCtx, statedb, chainConfig, vm.Config{})
+	evm.SetTxContext(vm.TxContext{
+		Origin:     sender,
+		GasPrice:   gasPrice,
+		BlobHashes: tx.BlobHashes(),
+		BlobFeeCap: tx.BlobGasFeeCap(),
+	})
+	statedb.Prepare(rules, sender, blockCtx.Coinbase, tx.To(), vm.ActivePrecompiles(rules), tx.AccessList())
+	applyAuthorizations(statedb, chainConfig.ChainID, tx.SetCodeAuthorizations(), authorities)
+
+	var (
+		ret     []byte
+		gasLeft uint64
+		execErr error
+		value   = uint256.MustFromBig(tx.Value())
+	)
+	if tx.To() == nil {
+		ret, _, gasLeft, execErr = evm.Create(sender, tx.Data(), tx.Gas()-intrinsic, value)
+	} else {
+		statedb.SetNonce(sender, tx.Nonce()+1, tracing.NonceChangeEoACall)
+		ret, gasLeft, execErr = evm.Call(sender, *tx.To(), tx.Data(), tx.Gas()-intrinsic, value)
+	}
+
+	// gas used 扣除 EIP-3529 退款上限，Prague 之后不低于 EIP-7623 的 calldata 下限
+	gasUsed := tx.Gas() - gasLeft
+	gasUsed -= min(statedb.GetRefund(), gasUsed/params.RefundQuotientEIP3529)
+	if rules.IsPrague {
+		gasUsed = max(gasUsed, floorDataGas(tx.Data()))
+	}
+	result := &wallet.SimulationResult{GasUsed: gasUsed}
+	if execErr != nil {
+		result.Reverted = true
+		result.RevertReason = revertReason(ret, execErr)
+		return result, nil
+	}
+	for _, l := range statedb.Logs() {
+		if len(l.Topics) != 3 || l.Topics[0] != erc20TransferTopic || len(l.Data) != 32 {
+			continue
+		}
+		result.Transfers = append(result.Transfers, &wallet.TokenTransfer{
+			Token:  l.Address.Hex(),
+			From:   common.BytesToAddress(l.Topics[1][:]).Hex(),
+			To:     common.BytesToAddress(l.Topics[2][:]).Hex(),
+			Amount: new(big.Int).SetBytes(l.Data).String(),
+		})
+	}
+	return result, nil
+}
+
+// checkSimulation 拒绝 revert 的交易，以及发送方转出的 ERC-20 超过声明数量的交易
+func checkSimulation(sender common.Address, tx *types.Transaction, sim *wallet.Simulation, result *wallet.SimulationResult) error {
+	if result.Reverted {
+		return policyError(wallet.RejectReason_REJECT_REASON_SIMULATION_REVERTED, "transaction reverted in simulation: %s", result.RevertReason)
+	}
+	declared := make(map[common.Address]*big.Int)
+	addDeclared := func(token common.Address, amount *big.Int) {
+		if declared[token] == nil {
+			declared[token] = new(big.Int)
+		}
+		declared[token].Add(declared[token], amount)
+	}
+	for i, outflow := range sim.MaxTokenOutflows {
+		if !common.IsHexAddress(outflow.Token) {
+			return fmt.Errorf("invalid max_token_outflows[%d] token: %s", i, outflow.Token)
+		}
+		amount, err := parseAmount(outflow.Amount)
+		if err != nil {
+			return fmt.Errorf("invalid max_token_outflows[%d] amount: %w", i, err)
+		}
+		addDeclared(common.HexToAddress(outflow.Token), amount)
+	}
+	// 直接调用 ERC-20 transfer / transferFrom 时，调用参数本身就是声明的数量
+	if tx.To() != nil {
+		if call, err := DecodeTokenCall(tx.Data()); err == nil && call != nil && call.Amount != nil {
+			fromSender := call.From == nil || *call.From == sender
+			if fromSender && ((call.Standard == TokenStandardErc20 && call.Method == "transfer") || call.Standard == TokenStandardErc20Or721) {
+				addDeclared(*tx.To(), call.Amount)
+			}
+		}
+	}
+
+	outflows := make(map[common.Address]*big.Int)
+	for _, transfer := range result.Transfers {
+		if common.HexToAddress(transfer.From) != sender {
+			continue
+		}
+		token := common.HexToAddress(transfer.Token)
+		amount, _ := new(big.Int).SetString(transfer.Amount, 10)
+		if outflows[token] == nil {
+			outflows[token] = new(big.Int)
+		}
+		outflows[token].Add(outflows[token], amount)
+	}
+	for token, outflow := range outflows {
+		allowed := declared[token]
+		if allowed == nil {
+			allowed = new(big.Int)
+		}
+		if outflow.Cmp(allowed) > 0 {
+			return policyError(wallet.RejectReason_REJECT_REASON_TOKEN_OUTFLOW_EXCEEDED, "simulation moves %s of token %s from %s, declared %s", outflow, token.Hex(), sender.Hex(), allowed)
+		}
+	}
+	return nil
+}
+
+func applyStateOverrides(statedb *state.StateDB, overrides []*wallet.StateOverride) error {
+	for i, override := range overrides {
+		if !common.IsHexAddress(override.Address) {
+			return fmt.Errorf("invalid state_overrides[%d] address: %s", i, override.Address)
+		}
+		address := common.HexToAddress(override.Address)
+		if override.Balance != "" {
+			balance, err := parseBoundedUint("balance", override.Balance, 256)
+			if err != nil {
+				return fmt.Errorf("state_overrides[%d]: %w", i, err)
+			}
+			statedb.SetBalance(address, uint256.MustFromBig(balance), tracing.BalanceChangeUnspecified)
+		}
+		statedb.SetNonce(address, override.Nonce, tracing.NonceChangeUnspecified)
+		code, err := parseOptionalHex("code", override.Code)
+		if err != nil {
+			return fmt.Errorf("state_overrides[%d]: %w", i, err)
+		}
+		if len(code) > 0 {
+			statedb.SetCode(address, code)
+		}
+		for slot, value := range override.Storage {
+			key, err := parseStorageWord(slot)
+			if err != nil {
+				return fmt.Errorf("state_overrides[%d] storage slot: %w", i, err)
+			}
+			word, err := parseStorageWord(value)
+			if err != nil {
+				return fmt.Errorf("state_overrides[%d] storage value: %w", i, err)
+			}
+			statedb.SetState(address, key, word)
+		}
+	}
+	return nil
+}
+
+func parseStorageWord(value string) (common.Hash, error) {
+	b, err := hexutil.Decode(value)
+	if err != nil || len(b) > common.HashLength {
+		return common.Hash{}, fmt.Errorf("invalid storage word: %s", value)
+	}
+	return common.BytesToHash(b), nil
+}
+
+// applyAuthorizations 按 EIP-7702 规则写入委托代码，无效的授权与链上一样直接跳过。
+// 托管授权在预执行时还没有签名，授权账户由调用方按公钥给出（authorities 与 authList 按下标对应）
+func applyAuthorizations(statedb *state.StateDB, chainID *big.Int, authList []types.SetCodeAuthorization, authorities []common.Address) {
+	for i, auth := range authList {
+		if !auth.ChainID.IsZero() && auth.ChainID.ToBig().Cmp(chainID) != 0 {
+			continue
+		}
+		if i >= len(authorities) || authorities[i] == (common.Address{}) {
+			continue
+		}
+		authority := authorities[i]
+		if statedb.GetNonce(authority) != auth.Nonce {
+			continue
+		}
+		statedb.SetNonce(authority, auth.Nonce+1, tracing.NonceChangeAuthorization)
+		if auth.Address == (common.Address{}) {
+			statedb.SetCode(authority, nil)
+			continue
+		}
+		statedb.SetCode(authority, types.AddressToDelegation(auth.Address))
+	}
+}
+
+// intrinsicGas 与 core.IntrinsicGas 的规则一致：基础费用、calldata、access list、initcode 与 7702 授权
+func intrinsicGas(tx *types.Transaction, rules params.Rules) uint64 {
+	gas := params.TxGas
+	if tx.To() == nil {
+		gas = params.TxGasContractCreation
+	}
+	for _, b := range tx.Data() {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	if tx.To() == nil && rules.IsShanghai {
+		gas += params.InitCodeWordGas * ((uint64(len(tx.Data())) + 31) / 32)
+	}
+	for _, tuple := range tx.AccessList() {
+		gas += params.TxAccessListAddressGas + uint64(len(tuple.StorageKeys))*params.TxAccessListStorageKeyGas
+	}
+	gas += uint64(len(tx.SetCodeAuthorizations())) * params.CallNewAccountGas
+	return gas
+}
+
+// floorDataGas 是 EIP-7623 的 calldata 最低 gas
+func floorDataGas(data []byte) uint64 {
+	var tokens uint64
+	for _, b := range data {
+		if b == 0 {
+			tokens++
+		} else {
+			tokens += params.TxTokenPerNonZeroByte
+		}
+	}
+	return params.TxGas + tokens*params.TxCostFloorPerToken
+}
+
+// revertReason 优先解析 Error(string) / Panic(uint256)，否则返回原始 revert 数据或 EVM 错误
+func revertReason(ret []byte, execErr error) string {
+	if !errors.Is(execErr, vm.ErrExecutionReverted) {
+		return execErr.Error()
+	}
+	if reason, err := abi.UnpackRevert(ret); err == nil {
+		return reason
+	}
+	if len(ret) > 0 {
+		return hexutil.Encode(ret)
+	}
+	return execErr.Error()
+}
+
+func canTransfer(db vm.StateDB, address common.Address, amount *uint256.Int) bool {
+	return db.GetBalance(address).Cmp(amount) >= 0
+}
+
+func transfer(db vm.StateDB, sender common.Address, recipient common.Address, amount *uint256.Int) {
+	db.SubBalance(sender, amount, tracing.BalanceChangeTransfer)
+	db.AddBalance(recipient, amount, tracing.BalanceChangeTransfer)
+}
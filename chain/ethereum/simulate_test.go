@@ -0,0 +1,175 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const testTokenAddress = "0x00000000000000000000000000000000000a11ce"
+
+// tokenRuntimeCode 返回一个极简的代币合约：任何调用都按 calldata 的 (address to, uint256 amount) 发出
+// Transfer(caller, to, amount*factor) 并返回 true，factor > 1 用来模拟多转走代币的恶意合约
+func tokenRuntimeCode(factor byte) string {
+	code := []byte{
+		0x60, 0x24, 0x35, 0x60, factor, 0x02, 0x60, 0x00, 0x52, // mstore(0, calldataload(36) * factor)
+		0x60, 0x04, 0x35, // topic2: to
+		0x33, // topic1: caller
+		0x7f, // topic0: PUSH32 Transfer 签名
+	}
+	code = append(code, erc20TransferTopic[:]...)
+	code = append(code,
+		0x60, 0x20, 0x60, 0x00, 0xa3, // log3(0, 32, ...)
+		0x60, 0x01, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3, // return true
+	)
+	return hexutil.Encode(code)
+}
+
+// revertRuntimeCode 返回一个总是以 Error(reason) revert 的合约
+func revertRuntimeCode(reason string) string {
+	data := hexutil.MustDecode("0x08c379a0")
+	data = append(data, common.LeftPadBytes([]byte{0x20}, 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(reason))).Bytes(), 32)...)
+	data = append(data, common.RightPadBytes([]byte(reason), 32)...)
+	var code []byte
+	for offset := 0; offset < len(data); offset += 32 {
+		word := common.RightPadBytes(data[offset:min(offset+32, len(data))], 32)
+		code = append(code, 0x7f)
+		code = append(code, word...)
+		code = append(code, 0x60, byte(offset), 0x52)
+	}
+	code = append(code, 0x60, byte(len(data)), 0x60, 0x00, 0xfd)
+	return hexutil.Encode(code)
+}
+
+func TestSimulateBeforeSigning(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	sender, _ := publicKeyToAddress(pubKey)
+	sign := func(nonce uint64, payload TxPayload, sim *wallet.Simulation) *wallet.BuildAndSignTransactionResponse {
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{
+			PublicKey: pubKey,
+			TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+				ChainId:              "1",
+				Nonce:                nonce,
+				GasLimit:             100000,
+				MaxFeePerGas:         "30000000000",
+				MaxPriorityFeePerGas: "1000000000",
+				TxPayload:            payload,
+			}),
+			Simulation: sim,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return resp
+	}
+	tokenSim := func(factor byte) *wallet.Simulation {
+		return &wallet.Simulation{StateOverrides: []*wallet.StateOverride{{Address: testTokenAddress, Code: tokenRuntimeCode(factor)}}}
+	}
+	erc20Transfer := TxPayload{ToAddress: testToAddress, ContractAddress: testTokenAddress, TokenType: TokenTypeErc20, Amount: "1000"}
+
+	// 直接 transfer：转出数量与调用参数一致，允许签名并返回 Transfer 事件
+	resp := sign(0, erc20Transfer, tokenSim(1))
+	if resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("erc20 transfer simulation fail: %s", resp.Message)
+	}
+	sim := resp.Simulation
+	if sim == nil || sim.Reverted || len(sim.Transfers) != 1 || sim.GasUsed <= 21000 {
+		t.Fatalf("unexpected simulation result: %v", sim)
+	}
+	transfer := sim.Transfers[0]
+	if transfer.From != sender.Hex() || transfer.To != common.HexToAddress(testToAddress).Hex() || transfer.Amount != "1000" {
+		t.Errorf("unexpected transfer log: %v", transfer)
+	}
+
+	// 合约实际转走的数量超过 transfer 参数
+	resp = sign(1, erc20Transfer, tokenSim(2))
+	if resp.RejectReason != wallet.RejectReason_REJECT_REASON_TOKEN_OUTFLOW_EXCEEDED || resp.Simulation == nil || resp.Simulation.Transfers[0].Amount != "2000" {
+		t.Errorf("outflow above transfer amount must be rejected: %v", resp)
+	}
+
+	// 通用合约调用没有隐含声明，需要 max_token_outflows 覆盖
+	call := TxPayload{
+		ContractAddress: testTokenAddress,
+		Data:            hexutil.Encode(append(hexutil.MustDecode("0x12345678"), BuildErc20Data(common.HexToAddress(testToAddress), big.NewInt(500))[4:]...)),
+	}
+	if resp = sign(1, call, tokenSim(1)); resp.RejectReason != wallet.RejectReason_REJECT_REASON_TOKEN_OUTFLOW_EXCEEDED {
+		t.Errorf("undeclared outflow must be rejected: %v", resp)
+	}
+	declared := tokenSim(1)
+	declared.MaxTokenOutflows = []*wallet.TokenAmount{{Token: testTokenAddress, Amount: "500"}}
+	if resp = sign(1, call, declared); resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Errorf("declared outflow must be signed: %s", resp.Message)
+	}
+
+	// revert 的交易拒绝签名，并返回解析后的 revert reason
+	reverting := &wallet.Simulation{StateOverrides: []*wallet.StateOverride{{Address: testTokenAddress, Code: revertRuntimeCode("insufficient balance")}}}
+	resp = sign(2, erc20Transfer, reverting)
+	if resp.RejectReason != wallet.RejectReason_REJECT_REASON_SIMULATION_REVERTED || resp.Simulation.GetRevertReason() != "insufficient balance" {
+		t.Errorf("reverting tx must be rejected with reason: %v", resp)
+	}
+
+	// 原生币转账需要 state override 提供余额
+	native := TxPayload{ToAddress: testToAddress, Amount: "1000000000000000000"}
+	if resp = sign(2, native, &wallet.Simulation{}); resp.RejectReason != wallet.RejectReason_REJECT_REASON_SIMULATION_REVERTED || !strings.Contains(resp.Message, "insufficient balance") {
+		t.Errorf("native transfer without balance must be rejected: %v", resp)
+	}
+	funded := &wallet.Simulation{StateOverrides: []*wallet.StateOverride{{Address: sender.Hex(), Balance: "2000000000000000000"}}}
+	if resp = sign(2, native, funded); resp.Code != wallet.ReturnCode_SUCCESS || resp.Simulation.GasUsed != 21000 {
+		t.Errorf("funded native transfer: got %v", resp)
+	}
+
+	badOverride := &wallet.Simulation{StateOverrides: []*wallet.StateOverride{{Address: testTokenAddress, Storage: map[string]string{"0x01": "not hex"}}}}
+	if resp = sign(3, erc20Transfer, badOverride); resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, "storage value") {
+		t.Errorf("invalid storage override must be rejected: %v", resp)
+	}
+}
+
+func TestSimulationChainConfig(t *testing.T) {
+	var mainnet *evmNetwork
+	if chainConfig, err := mainnet.simulationChainConfig(common.Big1); err != nil || chainConfig != params.MainnetChainConfig {
+		t.Errorf("chain 1 must use the mainnet schedule: %v", err)
+	}
+
+	// 未配置 simulation_fork 的 L2 不能按主网规则预执行
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{Name: "Base", ChainId: 8453, Symbol: "ETH"})
+	req := &wallet.BuildAndSignTransactionRequest{
+		PublicKey: pubKey,
+		TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+			ChainId:              "8453",
+			GasLimit:             21000,
+			MaxFeePerGas:         "30000000000",
+			MaxPriorityFeePerGas: "1000000000",
+			TxPayload:            TxPayload{ToAddress: testToAddress, Amount: "1"},
+		}),
+		Simulation: &wallet.Simulation{},
+	}
+	resp, _ := c.BuildAndSignTransaction(context.Background(), req)
+	if resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, "simulation is not supported on chain 8453") {
+		t.Fatalf("simulation without fork config must be rejected: %v", resp)
+	}
+
+	c, pubKey = newTestNetworkAdaptor(t, config.EvmNetwork{Name: "Base", ChainId: 8453, Symbol: "ETH", SimulationFork: SimulationForkCancun})
+	sender, _ := publicKeyToAddress(pubKey)
+	req.PublicKey = pubKey
+	req.Simulation = &wallet.Simulation{StateOverrides: []*wallet.StateOverride{{Address: sender.Hex(), Balance: "2000000000000000000"}}}
+	if resp, _ = c.BuildAndSignTransaction(context.Background(), req); resp.Code != wallet.ReturnCode_SUCCESS || resp.Simulation == nil {
+		t.Fatalf("simulation with fork config fail: %v", resp)
+	}
+	chainConfig, _ := c.network.simulationChainConfig(big.NewInt(8453))
+	rules := chainConfig.Rules(common.Big1, true, 1)
+	if !rules.IsCancun || rules.IsPrague || chainConfig.ChainID.Uint64() != 8453 {
+		t.Errorf("unexpected rules for cancun fork: %+v", rules)
+	}
+
+	if _, err := newEvmNetwork(&config.EvmNetwork{Name: "Base", ChainId: 8453, SimulationFork: "osaka"}); err == nil || !strings.Contains(err.Error(), "unknown simulation fork") {
+		t.Errorf("unknown simulation fork must be rejected: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SiweVersion 是 EIP-4361 目前唯一的版本
+const SiweVersion = "1"
+
+var (
+	siweSchemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+\-.]*$`)
+	siweNoncePattern  = regexp.MustCompile(`^[A-Za-z0-9]{8,}$`)
+)
+
+// SiweMessage 是校验过的 EIP-4361 消息，String 按规范 ABNF 渲染成待签名文本
+type SiweMessage struct {
+	Scheme         string
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        *big.Int
+	Nonce          string
+	IssuedAt       string
+	ExpirationTime string
+	NotBefore      string
+	RequestID      string
+	Resources      []string
+}
+
+// String 渲染 EIP-4361 文本：地址使用 EIP-55 校验和格式，可选字段缺省时整行省略
+func (m *SiweMessage) String() string {
+	var b strings.Builder
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + " wants you to sign in with your Ethereum account:\n")
+	b.WriteString(m.Address.Hex() + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + m.Version + "\n")
+	b.WriteString("Chain ID: " + m.ChainID.String() + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + m.IssuedAt)
+	if m.ExpirationTime != "" {
+		b.WriteString("\nExpiration Time: " + m.ExpirationTime)
+	}
+	if m.NotBefore != "" {
+		b.WriteString("\nNot Before: " + m.NotBefore)
+	}
+	if m.RequestID != "" {
+		b.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+	return b.String()
+}
+
+// parseSiweMessage 校验请求中的 SIWE 字段；address 由调用方按签名公钥确定后传入，issued_at 为空时取 now
+func parseSiweMessage(req *wallet.SiweMessage, address common.Address, now time.Time) (*SiweMessage, error) {
+	if req == nil {
+		return nil, errors.New("siwe is required")
+	}
+	msg := &SiweMessage{
+		Scheme:         req.Scheme,
+		Domain:         req.Domain,
+		Address:        address,
+		Statement:      req.Statement,
+		URI:            req.Uri,
+		Version:        req.Version,
+		Nonce:          req.Nonce,
+		IssuedAt:       req.IssuedAt,
+		ExpirationTime: req.ExpirationTime,
+		NotBefore:      req.NotBefore,
+		RequestID:      req.RequestId,
+		Resources:      req.Resources,
+	}
+	if msg.Scheme != "" && !siweSchemePattern.MatchString(msg.Scheme) {
+		return nil, fmt.Errorf("invalid scheme: %s", msg.Scheme)
+	}
+	if msg.Domain == "" || strings.ContainsAny(msg.Domain, " \t\r\n/?#") {
+		return nil, fmt.Errorf("invalid domain: %q", msg.Domain)
+	}
+	if strings.ContainsAny(msg.Statement, "\r\n") {
+		return nil, errors.New("statement must not contain line breaks")
+	}
+	if err := checkSiweURI("uri", msg.URI); err != nil {
+		return nil, err
+	}
+	if msg.Version == "" {
+		msg.Version = SiweVersion
+	}
+	if msg.Version != SiweVersion {
+		return nil, fmt.Errorf("unsupported siwe version: %s", msg.Version)
+	}
+	chainID, ok := new(big.Int).SetString(req.ChainId, 10)
+	if !ok || chainID.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid chain ID: %s", req.ChainId)
+	}
+	msg.ChainID = chainID
+	if !siweNoncePattern.MatchString(msg.Nonce) {
+		return nil, errors.New("nonce must be at least 8 alphanumeric characters")
+	}
+	if strings.ContainsAny(msg.RequestID, "\r\n") {
+		return nil, errors.New("request_id must not contain line breaks")
+	}
+	for i, resource := range msg.Resources {
+		if err := checkSiweURI(fmt.Sprintf("resources[%d]", i), resource); err != nil {
+			return nil, err
+		}
+	}
+
+	if msg.IssuedAt == "" {
+		msg.IssuedAt = now.UTC().Format(time.RFC3339)
+	}
+	issuedAt, err := time.Parse(time.RFC3339, msg.IssuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issued_at: %w", err)
+	}
+	if msg.ExpirationTime != "" {
+		expirationTime, err := time.Parse(time.RFC3339, msg.ExpirationTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration_time: %w", err)
+		}
+		if !expirationTime.After(issuedAt) {
+			return nil, errors.New("expiration_time must be after issued_at")
+		}
+		// 已过期的登录消息签了也无法通过 dApp 校验，直接拒绝
+		if !expirationTime.After(now) {
+			return nil, fmt.Errorf("siwe message expired at %s", msg.ExpirationTime)
+		}
+	}
+	if msg.NotBefore != "" {
+		if _, err := time.Parse(time.RFC3339, msg.NotBefore); err != nil {
+			return nil, fmt.Errorf("invalid not_before: %w", err)
+		}
+	}
+	return msg, nil
+}
+
+// checkSiweURI 要求 RFC 3986 绝对 URI，且不能含换行破坏消息结构
+func checkSiweURI(name string, value string) error {
+	if strings.ContainsAny(value, " \t\r\n") {
+		return fmt.Errorf("invalid %s: %q", name, value)
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return fmt.Errorf("invalid %s: %q", name, value)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package ethereum
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-4361 规范中的示例消息
+const eip4361Example = `service.org wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.org/tos
+
+URI: https://service.org/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestSiweMessageString(t *testing.T) {
+	req := &wallet.SiweMessage{
+		Domain:    "service.org",
+		Statement: "I accept the ServiceOrg Terms of Service: https://service.org/tos",
+		Uri:       "https://service.org/login",
+		ChainId:   "1",
+		Nonce:     "32891756",
+		IssuedAt:  "2021-09-30T16:25:24Z",
+		Resources: []string{"ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/", "https://example.com/my-web2-claim.json"},
+	}
+	// 地址以小写传入，渲染时必须是 EIP-55 格式
+	msg, err := parseSiweMessage(req, common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"), time.Now())
+	if err != nil {
+		t.Fatalf("parse siwe message: %v", err)
+	}
+	if got := msg.String(); got != eip4361Example {
+		t.Fatalf("siwe message mismatch:\n%s\nwant:\n%s", got, eip4361Example)
+	}
+
+	// 没有 statement 时保留两行空行；可选字段按规范顺序追加
+	req.Scheme = "https"
+	req.Statement = ""
+	req.Resources = nil
+	req.ExpirationTime = "2099-01-01T00:00:00Z"
+	req.RequestId = "req-1"
+	msg, _ = parseSiweMessage(req, common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"), time.Now())
+	want := "https://service.org wants you to sign in with your Ethereum account:\n" +
+		"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\n" +
+		"URI: https://service.org/login\nVersion: 1\nChain ID: 1\nNonce: 32891756\n" +
+		"Issued At: 2021-09-30T16:25:24Z\nExpiration Time: 2099-01-01T00:00:00Z\nRequest ID: req-1"
+	if got := msg.String(); got != want {
+		t.Fatalf("siwe message without statement mismatch:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestSignSiweMessage(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	signer, _ := publicKeyToAddress(pubKey)
+	base := func() *wallet.SignSiweMessageRequest {
+		return &wallet.SignSiweMessageRequest{
+			PublicKey: pubKey,
+			Siwe: &wallet.SiweMessage{
+				Domain:  "app.example.com",
+				Uri:     "https://app.example.com/login",
+				ChainId: "1",
+				Nonce:   "a1b2c3d4e5",
+			},
+		}
+	}
+
+	resp, err := c.SignSiweMessage(context.Background(), base())
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign siwe message fail: resp=%v err=%v", resp, err)
+	}
+	digest := PersonalMessageHash([]byte(resp.SiweMessage))
+	if resp.Digest != digest.Hex() {
+		t.Fatalf("digest must be the personal_sign hash of the rendered message")
+	}
+	sig := hexutil.MustDecode(resp.Signature)
+	sig[crypto.RecoveryIDOffset] -= 27
+	recovered, err := crypto.SigToPub(digest[:], sig)
+	if err != nil || crypto.PubkeyToAddress(*recovered) != signer {
+		t.Fatalf("recovered signer mismatch: err=%v", err)
+	}
+
+	// 只传地址时按地址索引找到公钥
+	byAddress := base()
+	byAddress.PublicKey = ""
+	byAddress.Siwe.Address = signer.Hex()
+	if resp, _ := c.SignSiweMessage(context.Background(), byAddress); resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign by address fail: %s", resp.Message)
+	}
+
+	cases := map[string]func(req *wallet.SignSiweMessageRequest){
+		"other address":   func(req *wallet.SignSiweMessageRequest) { req.Siwe.Address = testToAddress },
+		"short nonce":     func(req *wallet.SignSiweMessageRequest) { req.Siwe.Nonce = "abc" },
+		"relative uri":    func(req *wallet.SignSiweMessageRequest) { req.Siwe.Uri = "/login" },
+		"newline domain":  func(req *wallet.SignSiweMessageRequest) { req.Siwe.Domain = "evil.com\nURI: x" },
+		"bad version":     func(req *wallet.SignSiweMessageRequest) { req.Siwe.Version = "2" },
+		"expired":         func(req *wallet.SignSiweMessageRequest) { req.Siwe.ExpirationTime = "2020-01-01T00:00:00Z" },
+		"bad issued at":   func(req *wallet.SignSiweMessageRequest) { req.Siwe.IssuedAt = "yesterday" },
+		"multi statement": func(req *wallet.SignSiweMessageRequest) { req.Siwe.Statement = "line1\nline2" },
+	}
+	for name, modify := range cases {
+		req := base()
+		modify(req)
+		resp, err := c.SignSiweMessage(context.Background(), req)
+		if err != nil || resp.Code != wallet.ReturnCode_ERROR || resp.Message == "" {
+			t.Errorf("%s: expected error response, got resp=%v err=%v", name, resp, err)
+		}
+	}
+}
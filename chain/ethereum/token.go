@@ -0,0 +1,227 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// nativeDecimals 是 EVM 原生币的精度
+const nativeDecimals = 18
+
+var decimalAmountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// tokenInfo 是代币注册表中的一个 ERC-20
+type tokenInfo struct {
+	address  common.Address
+	symbol   string
+	decimals uint8
+}
+
+// tokenRegistry 按 symbol（大写）和合约地址索引网络上登记的 ERC-20
+type tokenRegistry struct {
+	bySymbol  map[string]*tokenInfo
+	byAddress map[common.Address]*tokenInfo
+}
+
+func newTokenRegistry(networkConf *config.EvmNetwork) (*tokenRegistry, error) {
+	registry := &tokenRegistry{
+		bySymbol:  make(map[string]*tokenInfo, len(networkConf.Tokens)),
+		byAddress: make(map[common.Address]*tokenInfo, len(networkConf.Tokens)),
+	}
+	for _, token := range networkConf.Tokens {
+		if !common.IsHexAddress(token.Address) {
+			return nil, fmt.Errorf("token %s: invalid address %s", token.Symbol, token.Address)
+		}
+		symbol := strings.ToUpper(token.Symbol)
+		if symbol == "" || strings.ContainsAny(symbol, " \t") {
+			return nil, fmt.Errorf("token %s: invalid symbol %q", token.Address, token.Symbol)
+		}
+		if symbol == strings.ToUpper(networkConf.Symbol) {
+			return nil, fmt.Errorf("token %s: symbol conflicts with native symbol", token.Symbol)
+		}
+		info := &tokenInfo{address: common.HexToAddress(token.Address), symbol: token.Symbol, decimals: token.Decimals}
+		if registry.bySymbol[symbol] != nil {
+			return nil, fmt.Errorf("duplicate token symbol %s", token.Symbol)
+		}
+		if registry.byAddress[info.address] != nil {
+			return nil, fmt.Errorf("duplicate token address %s", token.Address)
+		}
+		registry.bySymbol[symbol] = info
+		registry.byAddress[info.address] = info
+	}
+	return registry, nil
+}
+
+// enforced 表示网络配置了代币注册表，此时未登记的 ERC-20 默认拒绝；未配置网络或注册表为空时保持原有行为
+func (r *tokenRegistry) enforced() bool {
+	return r != nil && len(r.byAddress) > 0
+}
+
+func (r *tokenRegistry) lookupSymbol(symbol string) *tokenInfo {
+	if r == nil {
+		return nil
+	}
+	return r.bySymbol[strings.ToUpper(symbol)]
+}
+
+func (r *tokenRegistry) lookupAddress(address common.Address) *tokenInfo {
+	if r == nil {
+		return nil
+	}
+	return r.byAddress[address]
+}
+
+// resolveTokenAmount 把 "12.5 USDC"、"0.1 ETH" 这类带 symbol 的金额换算成最小单位，返回改写后的 payload 副本；
+// 代币金额会补全或校验 contract_address，不带 symbol 的金额原样保留（最小单位）
+func (n *evmNetwork) resolveTokenAmount(payload *TxPayload) (*TxPayload, error) {
+	fields := strings.Fields(payload.Amount)
+	if len(fields) < 2 {
+		return payload, nil
+	}
+	if len(fields) > 2 {
+		return nil, fmt.Errorf("invalid amount: %s", payload.Amount)
+	}
+	value, symbol := fields[0], fields[1]
+	resolved := *payload
+
+	if n != nil && n.symbol != "" && strings.EqualFold(symbol, n.symbol) {
+		if payload.TokenType != "" && payload.TokenType != TokenTypeNative {
+			return nil, fmt.Errorf("amount in %s cannot be used for %s transfer", symbol, payload.TokenType)
+		}
+		if !isEthTransfer(payload) && !isContractCall(payload) && !isContractDeployment(payload) {
+			return nil, fmt.Errorf("amount in %s cannot be used with contract address %s", symbol, payload.ContractAddress)
+		}
+		amount, err := parseDecimalAmount(value, nativeDecimals)
+		if err != nil {
+			return nil, err
+		}
+		resolved.Amount = amount.String()
+		return &resolved, nil
+	}
+
+	token := n.tokens().lookupSymbol(symbol)
+	if token == nil {
+		return nil, policyError(wallet.RejectReason_REJECT_REASON_UNKNOWN_TOKEN, "unknown token symbol %s on %s", symbol, n.displayName())
+	}
+	if isContractCall(payload) || isContractDeployment(payload) || (payload.TokenType != "" && payload.TokenType != TokenTypeErc20) {
+		return nil, fmt.Errorf("amount in %s is only supported for erc20 transfers", token.symbol)
+	}
+	if payload.ContractAddress == "" {
+		resolved.ContractAddress = token.address.Hex()
+	} else if !common.IsHexAddress(payload.ContractAddress) || common.HexToAddress(payload.ContractAddress) != token.address {
+		return nil, fmt.Errorf("contract address %s does not match %s at %s", payload.ContractAddress, token.symbol, token.address.Hex())
+	}
+	amount, err := parseDecimalAmount(value, token.decimals)
+	if err != nil {
+		return nil, err
+	}
+	resolved.TokenType = TokenTypeErc20
+	resolved.Amount = amount.String()
+	return &resolved, nil
+}
+
+// checkTokenCall 按最终交易的 to / data 识别 ERC-20 transfer、approve、transferFrom，在配置了注册表的网络上拒绝未登记的代币，
+// 除非调用方显式设置 allow_unknown_token；erc20 转账、data、method + args 与 unsigned_tx 信封都经过这里。
+// 选择器命中但参数无法解析时无法确认调用内容，同样按未登记代币处理
+func (n *evmNetwork) checkTokenCall(contract common.Address, data []byte, allowUnknown bool) error {
+	registry := n.tokens()
+	if !registry.enforced() || allowUnknown || registry.lookupAddress(contract) != nil {
+		return nil
+	}
+	call, err := DecodeTokenCall(data)
+	if err == nil && (call == nil || (call.Standard != TokenStandardErc20 && call.Standard != TokenStandardErc20Or721)) {
+		return nil
+	}
+	return policyError(wallet.RejectReason_REJECT_REASON_UNKNOWN_TOKEN, "token %s is not in the %s token registry, set allow_unknown_token to call it", contract.Hex(), n.name)
+}
+
+// displayAmount 返回交易转移金额的可读形式：原生币转账按网络 symbol，登记过的 ERC-20 transfer 按注册表精度；无法识别时为空
+func (n *evmNetwork) displayAmount(tx *types.Transaction) string {
+	if n == nil {
+		return ""
+	}
+	if len(tx.Data()) == 0 {
+		if tx.To() == nil || tx.Value().Sign() == 0 || n.symbol == "" {
+			return ""
+		}
+		return FormatTokenAmount(tx.Value(), nativeDecimals) + " " + n.symbol
+	}
+	if tx.To() == nil {
+		return ""
+	}
+	return n.displayTokenAmount(*tx.To(), tx.Data())
+}
+
+// displayTokenAmount 解析 ERC-20 transfer / transferFrom 调用，按注册表精度换算数量
+func (n *evmNetwork) displayTokenAmount(contract common.Address, data []byte) string {
+	token := n.tokens().lookupAddress(contract)
+	if token == nil {
+		return ""
+	}
+	call, err := DecodeTokenCall(data)
+	if err != nil || call == nil || call.Amount == nil {
+		return ""
+	}
+	if call.Standard != TokenStandardErc20 && call.Standard != TokenStandardErc20Or721 {
+		return ""
+	}
+	return FormatTokenAmount(call.Amount, token.decimals) + " " + token.symbol
+}
+
+func (n *evmNetwork) tokens() *tokenRegistry {
+	if n == nil {
+		return nil
+	}
+	return n.tokenRegistry
+}
+
+func (n *evmNetwork) displayName() string {
+	if n == nil {
+		return ChainName
+	}
+	return n.name
+}
+
+// parseDecimalAmount 把十进制金额按 decimals 换算成最小单位，小数位超过精度时报错而不是截断
+func parseDecimalAmount(value string, decimals uint8) (*big.Int, error) {
+	if !decimalAmountPattern.MatchString(value) {
+		return nil, fmt.Errorf("invalid amount: %s", value)
+	}
+	integer, fraction, _ := strings.Cut(value, ".")
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("amount %s has more than %d decimal places", value, decimals)
+	}
+	amount, ok := new(big.Int).SetString(integer+fraction+strings.Repeat("0", int(decimals)-len(fraction)), 10)
+	if !ok {
+		return nil, errors.New("invalid amount: " + value)
+	}
+	return amount, nil
+}
+
+// FormatTokenAmount 按 decimals 把最小单位格式化成十进制字符串，去掉小数部分末尾的 0
+func FormatTokenAmount(amount *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(amount).String()
+	if decimals > 0 {
+		if len(digits) <= int(decimals) {
+			digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+		}
+		point := len(digits) - int(decimals)
+		integer, fraction := digits[:point], strings.TrimRight(digits[point:], "0")
+		digits = integer
+		if fraction != "" {
+			digits += "." + fraction
+		}
+	}
+	if amount.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
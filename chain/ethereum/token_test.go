@@ -0,0 +1,207 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Brant-Liang/wallet-sign/config"
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const testUsdcAddress = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+
+func TestDecimalAmounts(t *testing.T) {
+	cases := []struct {
+		value    string
+		decimals uint8
+		want     string
+	}{
+		{"12.5", 6, "12500000"},
+		{"0.000001", 6, "1"},
+		{"1", 18, "1000000000000000000"},
+		{"100", 0, "100"},
+	}
+	for _, tc := range cases {
+		amount, err := parseDecimalAmount(tc.value, tc.decimals)
+		if err != nil || amount.String() != tc.want {
+			t.Errorf("parse %s with %d decimals: got %v err=%v, want %s", tc.value, tc.decimals, amount, err, tc.want)
+			continue
+		}
+		if formatted := FormatTokenAmount(amount, tc.decimals); formatted != tc.value {
+			t.Errorf("format %s with %d decimals: got %s, want %s", amount, tc.decimals, formatted, tc.value)
+		}
+	}
+	for _, value := range []string{"1.0000001", "-1", "1e6", ".5", "1,5"} {
+		if _, err := parseDecimalAmount(value, 6); err == nil {
+			t.Errorf("%s: expected error", value)
+		}
+	}
+	if got := FormatTokenAmount(big.NewInt(1200000), 6); got != "1.2" {
+		t.Errorf("trailing zeros must be trimmed: got %s", got)
+	}
+}
+
+func TestTokenRegistry(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{
+		Name:    "Ethereum",
+		ChainId: 1,
+		Symbol:  "ETH",
+		Tokens:  []config.EvmToken{{Address: testUsdcAddress, Symbol: "USDC", Decimals: 6}},
+	})
+	nonce := uint64(0)
+	sign := func(payload TxPayload) *wallet.BuildAndSignTransactionResponse {
+		nonce++
+		resp, err := c.BuildAndSignTransaction(context.Background(), &wallet.BuildAndSignTransactionRequest{
+			PublicKey: pubKey,
+			TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+				ChainId:              "1",
+				Nonce:                nonce,
+				GasLimit:             100000,
+				MaxFeePerGas:         "30000000000",
+				MaxPriorityFeePerGas: "1000000000",
+				TxPayload:            payload,
+			}),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return resp
+	}
+
+	// 按 symbol 指定金额，合约地址由注册表补全
+	resp := sign(TxPayload{ToAddress: testToAddress, Amount: "12.5 usdc"})
+	if resp.Code != wallet.ReturnCode_SUCCESS || resp.DisplayAmount != "12.5 USDC" {
+		t.Fatalf("symbol amount: got %v", resp)
+	}
+	tx := decodeSignedTx(t, resp.SignedTx)
+	call, _ := DecodeTokenCall(tx.Data())
+	if *tx.To() != common.HexToAddress(testUsdcAddress) || call == nil || call.Amount.String() != "12500000" {
+		t.Fatalf("symbol amount must be converted with token decimals: to=%s call=%+v", tx.To(), call)
+	}
+	decoded, _ := c.DecodeTransaction(context.Background(), &wallet.DecodeTransactionRequest{RawTx: resp.SignedTx})
+	if decoded.TokenCall.GetDisplayAmount() != "12.5 USDC" {
+		t.Errorf("decoded token call display amount: got %q", decoded.TokenCall.GetDisplayAmount())
+	}
+
+	// 最小单位金额 + 已登记合约仍然可用
+	if resp = sign(TxPayload{ToAddress: testToAddress, ContractAddress: testUsdcAddress, Amount: "1500000"}); resp.DisplayAmount != "1.5 USDC" {
+		t.Errorf("base unit amount: got %v", resp)
+	}
+	if resp = sign(TxPayload{ToAddress: testToAddress, Amount: "0.25 ETH"}); resp.Code != wallet.ReturnCode_SUCCESS || resp.DisplayAmount != "0.25 ETH" {
+		t.Errorf("native symbol amount: got %v", resp)
+	}
+	if tx = decodeSignedTx(t, resp.SignedTx); tx.Value().String() != "250000000000000000" {
+		t.Errorf("native amount: got %s", tx.Value())
+	}
+
+	unknownToken := TxPayload{ToAddress: testToAddress, ContractAddress: testTokenAddress, Amount: "1000"}
+	cases := map[string]struct {
+		payload TxPayload
+		want    string
+		reason  wallet.RejectReason
+	}{
+		"unknown token":        {unknownToken, "not in the Ethereum token registry", wallet.RejectReason_REJECT_REASON_UNKNOWN_TOKEN},
+		"unknown symbol":       {TxPayload{ToAddress: testToAddress, Amount: "1 FOO"}, "unknown token symbol FOO", wallet.RejectReason_REJECT_REASON_UNKNOWN_TOKEN},
+		"too many decimals":    {TxPayload{ToAddress: testToAddress, Amount: "1.0000001 USDC"}, "more than 6 decimal places", wallet.RejectReason_REJECT_REASON_NONE},
+		"contract mismatch":    {TxPayload{ToAddress: testToAddress, ContractAddress: testTokenAddress, Amount: "1 USDC"}, "does not match USDC", wallet.RejectReason_REJECT_REASON_NONE},
+		"symbol on nft":        {TxPayload{FromAddress: testToAddress, ToAddress: testToAddress, TokenType: TokenTypeErc721, TokenId: "1", Amount: "1 USDC"}, "only supported for erc20", wallet.RejectReason_REJECT_REASON_NONE},
+		"native with contract": {TxPayload{ToAddress: testToAddress, ContractAddress: testUsdcAddress, Amount: "1 ETH"}, "cannot be used with contract address", wallet.RejectReason_REJECT_REASON_NONE},
+	}
+	for name, tc := range cases {
+		resp := sign(tc.payload)
+		if resp.Code != wallet.ReturnCode_ERROR || !strings.Contains(resp.Message, tc.want) || resp.RejectReason != tc.reason {
+			t.Errorf("%s: got code=%v reason=%v message=%q, want error containing %q", name, resp.Code, resp.RejectReason, resp.Message, tc.want)
+		}
+	}
+
+	// 显式放行未登记代币；无法换算精度时不返回可读金额
+	unknownToken.AllowUnknownToken = true
+	if resp = sign(unknownToken); resp.Code != wallet.ReturnCode_SUCCESS || resp.DisplayAmount != "" {
+		t.Errorf("allowed unknown token: got %v", resp)
+	}
+}
+
+func TestTokenRegistryAppliesToEveryRequestPath(t *testing.T) {
+	c, pubKey := newTestNetworkAdaptor(t, config.EvmNetwork{
+		Name:    "Ethereum",
+		ChainId: 1,
+		Symbol:  "ETH",
+		Tokens:  []config.EvmToken{{Address: testUsdcAddress, Symbol: "USDC", Decimals: 6}},
+	})
+	transferData := hexutil.Encode(BuildErc20Data(common.HexToAddress(testToAddress), big.NewInt(1000)))
+	transferArgs := []byte(`["` + testToAddress + `","1000"]`)
+	body := func(nonce uint64, payload TxPayload) *wallet.BuildAndSignTransactionRequest {
+		return &wallet.BuildAndSignTransactionRequest{
+			PublicKey: pubKey,
+			TxBase64Body: encodeTxBody(t, Eip1559DynamicFeeTx{
+				ChainId:              "1",
+				Nonce:                nonce,
+				GasLimit:             100000,
+				MaxFeePerGas:         "30000000000",
+				MaxPriorityFeePerGas: "1000000000",
+				TxPayload:            payload,
+			}),
+		}
+	}
+	envelope := func(nonce uint64, token string, data string) *wallet.BuildAndSignTransactionRequest {
+		to := common.HexToAddress(token)
+		payload, _ := rlp.EncodeToBytes(unsignedDynamicFeeEnvelope{
+			ChainID:   common.Big1,
+			Nonce:     nonce,
+			GasTipCap: big.NewInt(1000000000),
+			GasFeeCap: big.NewInt(30000000000),
+			Gas:       100000,
+			To:        &to,
+			Value:     new(big.Int),
+			Data:      hexutil.MustDecode(data),
+		})
+		return &wallet.BuildAndSignTransactionRequest{PublicKey: pubKey, UnsignedTx: hexutil.Encode(append([]byte{types.DynamicFeeTxType}, payload...))}
+	}
+
+	rejected := map[string]*wallet.BuildAndSignTransactionRequest{
+		"data":            body(0, TxPayload{ContractAddress: testTokenAddress, Data: transferData}),
+		"method and args": body(0, TxPayload{ContractAddress: testTokenAddress, Method: "transfer(address,uint256)", Args: transferArgs}),
+		"approve":         body(0, TxPayload{ContractAddress: testTokenAddress, Method: "approve(address,uint256)", Args: transferArgs}),
+		"unsigned_tx":     envelope(0, testTokenAddress, transferData),
+		"malformed args":  body(0, TxPayload{ContractAddress: testTokenAddress, Data: transferData[:10] + "beef"}),
+	}
+	for name, req := range rejected {
+		resp, _ := c.BuildAndSignTransaction(context.Background(), req)
+		if resp.Code != wallet.ReturnCode_ERROR || resp.RejectReason != wallet.RejectReason_REJECT_REASON_UNKNOWN_TOKEN {
+			t.Errorf("%s: transfer of unknown token must be rejected, got %v", name, resp)
+		}
+	}
+
+	allowed := map[string]*wallet.BuildAndSignTransactionRequest{
+		"registered token data":  body(1, TxPayload{ContractAddress: testUsdcAddress, Data: transferData}),
+		"registered unsigned_tx": envelope(2, testUsdcAddress, transferData),
+		"explicitly allowed":     body(3, TxPayload{ContractAddress: testTokenAddress, Method: "transfer(address,uint256)", Args: transferArgs, AllowUnknownToken: true}),
+		"non token call":         body(4, TxPayload{ContractAddress: testTokenAddress, Data: "0x12345678"}),
+	}
+	for name, req := range allowed {
+		if resp, _ := c.BuildAndSignTransaction(context.Background(), req); resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Errorf("%s: got %v", name, resp)
+		}
+	}
+}
+
+func TestTokenRegistryValidation(t *testing.T) {
+	usdc := config.EvmToken{Address: testUsdcAddress, Symbol: "USDC", Decimals: 6}
+	cases := map[string][]config.EvmToken{
+		"duplicate symbol":  {usdc, {Address: testTokenAddress, Symbol: "usdc", Decimals: 6}},
+		"duplicate address": {usdc, {Address: testUsdcAddress, Symbol: "USDC2", Decimals: 6}},
+		"native symbol":     {{Address: testTokenAddress, Symbol: "ETH", Decimals: 18}},
+		"bad address":       {{Address: "0x1234", Symbol: "FOO", Decimals: 18}},
+	}
+	for name, tokens := range cases {
+		if _, err := newEvmNetwork(&config.EvmNetwork{Name: "Ethereum", ChainId: 1, Symbol: "ETH", Tokens: tokens}); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
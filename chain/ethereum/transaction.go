@@ -5,8 +5,11 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/pkg/errors"
 )
@@ -28,9 +31,10 @@ func BuildErc20Data(to common.Address, amount *big.Int) []byte {
 	return data
 }
 
-func BuildErc721Data(from, to common.Address, tokenId *big.Int) []byte {
-	if tokenId == nil || tokenId.Sign() < 0 {
-		panic("invalid tokenId")
+// ERC-721 的 safeTransferFrom(address,address,uint256)
+func BuildErc721Data(from, to common.Address, tokenId *big.Int) ([]byte, error) {
+	if tokenId == nil || tokenId.Sign() < 0 || tokenId.BitLen() > 256 {
+		return nil, errors.New("invalid tokenId")
 	}
 	sig := []byte("safeTransferFrom(address,address,uint256)")
 	methodID := crypto.Keccak256(sig)[:4]
@@ -44,7 +48,39 @@ func BuildErc721Data(from, to common.Address, tokenId *big.Int) []byte {
 	data = append(data, f...)
 	data = append(data, t...)
 	data = append(data, id...)
-	return data
+	return data, nil
+}
+
+// ERC-1155 的 safeTransferFrom(address,address,uint256,uint256,bytes)
+func BuildErc1155Data(from, to common.Address, tokenId *big.Int, amount *big.Int, extraData []byte) ([]byte, error) {
+	if tokenId == nil || tokenId.Sign() < 0 || tokenId.BitLen() > 256 {
+		return nil, errors.New("invalid tokenId")
+	}
+	if amount == nil || amount.Sign() < 0 || amount.BitLen() > 256 {
+		return nil, errors.New("invalid amount")
+	}
+	sig := []byte("safeTransferFrom(address,address,uint256,uint256,bytes)")
+	methodID := crypto.Keccak256(sig)[:4]
+
+	f := common.LeftPadBytes(from.Bytes(), 32)
+	t := common.LeftPadBytes(to.Bytes(), 32)
+	id := common.LeftPadBytes(tokenId.Bytes(), 32)
+	amt := common.LeftPadBytes(amount.Bytes(), 32)
+	// bytes 是动态类型：head 中放 offset（5 个参数 * 32 = 0xa0），tail 中放长度与右补零的内容
+	offset := common.LeftPadBytes(big.NewInt(5*32).Bytes(), 32)
+	length := common.LeftPadBytes(big.NewInt(int64(len(extraData))).Bytes(), 32)
+	paddedData := common.RightPadBytes(extraData, (len(extraData)+31)/32*32)
+
+	data := make([]byte, 0, 4+32*7+len(paddedData))
+	data = append(data, methodID...)
+	data = append(data, f...)
+	data = append(data, t...)
+	data = append(data, id...)
+	data = append(data, amt...)
+	data = append(data, offset...)
+	data = append(data, length...)
+	data = append(data, paddedData...)
+	return data, nil
 }
 
 // 生成 未签名交易的哈希（digest），后续要拿去做签名的那个消息哈希
@@ -56,6 +92,13 @@ func CreateLegacyUnSignTx(txData *types.LegacyTx, chainId *big.Int) common.Hash
 	return signer.Hash(tx)
 }
 
+// txData → EIP-2930 类型的交易数据（AccessListTx，在 Legacy 基础上增加 ChainID 与 AccessList）。
+func CreateAccessListUnSignTx(txData *types.AccessListTx, chainId *big.Int) common.Hash {
+	tx := types.NewTx(txData)
+	signer := types.LatestSignerForChainID(chainId)
+	return signer.Hash(tx)
+}
+
 // txData → EIP-1559 类型的交易数据（DynamicFeeTx，包括 MaxFeePerGas, MaxPriorityFeePerGas 等字段）。
 // chainId → 指定的链 ID。
 func CreateEip1559UnSignTx(txData *types.DynamicFeeTx, chainId *big.Int) common.Hash {
@@ -84,6 +127,86 @@ func CreateLegacySignedTx(txData *types.LegacyTx, sig []byte, chainId *big.Int)
 	return "0x" + hex.EncodeToString(enc), signedTx.Hash().String(), nil
 }
 
+func CreateAccessListSignedTx(txData *types.AccessListTx, sig []byte, chainId *big.Int) (rawHex string, txHash string, err error) {
+	if len(sig) != 65 {
+		return "", "", errors.New("invalid signature length")
+	}
+	tx := types.NewTx(txData)
+	signer := types.LatestSignerForChainID(chainId)
+
+	signedTx, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return "", "", errors.Wrap(err, "with signature")
+	}
+
+	// typed transaction：0x01 || rlp(payload)
+	enc, err2 := signedTx.MarshalBinary()
+	if err2 != nil {
+		return "", "", errors.Wrap(err2, "encode typed tx")
+	}
+
+	return "0x" + hex.EncodeToString(enc), signedTx.Hash().String(), nil
+}
+
+// txData → EIP-4844 类型的交易数据（BlobTx），签名只覆盖 blob versioned hash，不包含 sidecar。
+func CreateBlobUnSignTx(txData *types.BlobTx, chainId *big.Int) common.Hash {
+	tx := types.NewTx(txData)
+	signer := types.LatestSignerForChainID(chainId)
+	return signer.Hash(tx)
+}
+
+// CreateBlobSignedTx 返回带 sidecar 的网络格式（用于 eth_sendRawTransaction），txHash 不受 sidecar 影响
+func CreateBlobSignedTx(txData *types.BlobTx, sig []byte, chainId *big.Int) (rawHex string, txHash string, err error) {
+	if len(sig) != 65 {
+		return "", "", errors.New("invalid signature length")
+	}
+	if txData.Sidecar == nil {
+		return "", "", errors.New("blob tx sidecar is missing")
+	}
+	tx := types.NewTx(txData)
+	signer := types.LatestSignerForChainID(chainId)
+
+	signedTx, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return "", "", errors.Wrap(err, "with signature")
+	}
+
+	// 0x03 || rlp([tx_payload_body, blobs, commitments, proofs])
+	enc, err2 := signedTx.MarshalBinary()
+	if err2 != nil {
+		return "", "", errors.Wrap(err2, "encode blob tx")
+	}
+
+	return "0x" + hex.EncodeToString(enc), signedTx.Hash().String(), nil
+}
+
+// txData → EIP-7702 类型的交易数据（SetCodeTx），AuthList 中的授权必须在计算 digest 之前签好。
+func CreateSetCodeUnSignTx(txData *types.SetCodeTx, chainId *big.Int) common.Hash {
+	tx := types.NewTx(txData)
+	signer := types.LatestSignerForChainID(chainId)
+	return signer.Hash(tx)
+}
+
+func CreateSetCodeSignedTx(txData *types.SetCodeTx, sig []byte, chainId *big.Int) (rawHex string, txHash string, err error) {
+	if len(sig) != 65 {
+		return "", "", errors.New("invalid signature length")
+	}
+	tx := types.NewTx(txData)
+	signer := types.LatestSignerForChainID(chainId)
+
+	signedTx, err := tx.WithSignature(signer, sig)
+	if err != nil {
+		return "", "", errors.Wrap(err, "with signature")
+	}
+
+	enc, err2 := signedTx.MarshalBinary()
+	if err2 != nil {
+		return "", "", errors.Wrap(err2, "encode set code tx")
+	}
+
+	return "0x" + hex.EncodeToString(enc), signedTx.Hash().String(), nil
+}
+
 func CreateEip1559SignedTx(txData *types.DynamicFeeTx, sig []byte, chainId *big.Int) (types.Signer, *types.Transaction, string, string, error) {
 	// r(32)||s(32)||v(1)
 	if len(sig) != 65 {
@@ -105,3 +228,69 @@ func CreateEip1559SignedTx(txData *types.DynamicFeeTx, sig []byte, chainId *big.
 	rawHex := "0x" + hex.EncodeToString(enc)
 	return signer, signedTx, rawHex, signedTx.Hash().String(), nil
 }
+
+// BuildAccessList 校验并转换请求中的 access list：地址必须是 20 字节 hex，存储槽必须是 32 字节 hex
+func BuildAccessList(tuples []AccessTuple) (types.AccessList, error) {
+	if len(tuples) == 0 {
+		return nil, nil
+	}
+	accessList := make(types.AccessList, 0, len(tuples))
+	for i, tuple := range tuples {
+		if !common.IsHexAddress(tuple.Address) {
+			return nil, errors.Errorf("invalid access list address at %d: %s", i, tuple.Address)
+		}
+		storageKeys := make([]common.Hash, 0, len(tuple.StorageKeys))
+		for _, key := range tuple.StorageKeys {
+			keyBytes, err := hexutil.Decode(key)
+			if err != nil || len(keyBytes) != common.HashLength {
+				return nil, errors.Errorf("invalid storage key for %s: %s", tuple.Address, key)
+			}
+			storageKeys = append(storageKeys, common.BytesToHash(keyBytes))
+		}
+		accessList = append(accessList, types.AccessTuple{
+			Address:     common.HexToAddress(tuple.Address),
+			StorageKeys: storageKeys,
+		})
+	}
+	return accessList, nil
+}
+
+// BuildBlobSidecar 为每个 blob 计算 KZG commitment 与 proof；version 1 使用 EIP-7594 的 cell proofs
+func BuildBlobSidecar(blobs [][]byte, version byte) (*types.BlobTxSidecar, error) {
+	if len(blobs) == 0 || len(blobs) > params.BlobTxMaxBlobs {
+		return nil, errors.Errorf("blob count must be between 1 and %d", params.BlobTxMaxBlobs)
+	}
+	if version != types.BlobSidecarVersion0 && version != types.BlobSidecarVersion1 {
+		return nil, errors.Errorf("unsupported blob sidecar version: %d", version)
+	}
+	kzgBlobs := make([]kzg4844.Blob, len(blobs))
+	commitments := make([]kzg4844.Commitment, len(blobs))
+	proofs := make([]kzg4844.Proof, 0, len(blobs))
+	for i, data := range blobs {
+		if len(data) > len(kzgBlobs[i]) {
+			return nil, errors.Errorf("blob %d is %d bytes, max %d", i, len(data), len(kzgBlobs[i]))
+		}
+		copy(kzgBlobs[i][:], data)
+
+		commitment, err := kzg4844.BlobToCommitment(&kzgBlobs[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "blob %d commitment", i)
+		}
+		commitments[i] = commitment
+
+		if version == types.BlobSidecarVersion1 {
+			cellProofs, err := kzg4844.ComputeCellProofs(&kzgBlobs[i])
+			if err != nil {
+				return nil, errors.Wrapf(err, "blob %d cell proofs", i)
+			}
+			proofs = append(proofs, cellProofs...)
+			continue
+		}
+		proof, err := kzg4844.ComputeBlobProof(&kzgBlobs[i], commitment)
+		if err != nil {
+			return nil, errors.Wrapf(err, "blob %d proof", i)
+		}
+		proofs = append(proofs, proof)
+	}
+	return types.NewBlobTxSidecar(version, kzgBlobs, commitments, proofs), nil
+}
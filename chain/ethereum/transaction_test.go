@@ -0,0 +1,73 @@
+package ethereum
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestBuildAccessList(t *testing.T) {
+	slot := common.HexToHash("0x01").Hex()
+	accessList, err := BuildAccessList([]AccessTuple{
+		{Address: testToAddress, StorageKeys: []string{slot}},
+		{Address: "0x0000000000000000000000000000000000000001"},
+	})
+	if err != nil {
+		t.Fatalf("build access list: %v", err)
+	}
+	if len(accessList) != 2 || accessList.StorageKeys() != 1 {
+		t.Fatalf("unexpected access list: %+v", accessList)
+	}
+
+	invalid := map[string][]AccessTuple{
+		"bad address":     {{Address: "0x1234"}},
+		"short slot":      {{Address: testToAddress, StorageKeys: []string{"0x01"}}},
+		"slot without 0x": {{Address: testToAddress, StorageKeys: []string{slot[2:]}}},
+		"non hex slot":    {{Address: testToAddress, StorageKeys: []string{"0x" + strings.Repeat("zz", 32)}}},
+	}
+	for name, tuples := range invalid {
+		if _, err := BuildAccessList(tuples); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestBuildNftData(t *testing.T) {
+	from := common.HexToAddress("0x2f2a5B199438e31d46dEf97d90A51ac80c233bfF")
+	to := common.HexToAddress(testToAddress)
+	tokenId := big.NewInt(42)
+
+	erc721, err := BuildErc721Data(from, to, tokenId)
+	if err != nil {
+		t.Fatalf("build erc721 data: %v", err)
+	}
+	if got := hexutil.Encode(erc721[:4]); got != "0x42842e0e" {
+		t.Errorf("erc721 selector: got %s", got)
+	}
+	if _, err := BuildErc721Data(from, to, big.NewInt(-1)); err == nil {
+		t.Errorf("negative token id must be rejected")
+	}
+
+	extra := []byte{0xca, 0xfe}
+	erc1155, err := BuildErc1155Data(from, to, tokenId, big.NewInt(3), extra)
+	if err != nil {
+		t.Fatalf("build erc1155 data: %v", err)
+	}
+	method, err := abi.JSON(strings.NewReader(`[{"type":"function","name":"safeTransferFrom","inputs":[
+		{"type":"address"},{"type":"address"},{"type":"uint256"},{"type":"uint256"},{"type":"bytes"}]}]`))
+	if err != nil {
+		t.Fatalf("parse abi: %v", err)
+	}
+	want, err := method.Pack("safeTransferFrom", from, to, tokenId, big.NewInt(3), extra)
+	if err != nil {
+		t.Fatalf("abi pack: %v", err)
+	}
+	if !bytes.Equal(erc1155, want) {
+		t.Errorf("erc1155 data mismatch:\n got %x\nwant %x", erc1155, want)
+	}
+}
@@ -1,30 +1,124 @@
 package ethereum
 
+import "encoding/json"
+
+// tx_type 取值，决定交易体按哪种交易类型解析；为空时按 EIP-1559 处理，兼容旧请求
+const (
+	TxTypeLegacy     = "legacy"      // type-0，EIP-155 签名
+	TxTypeAccessList = "access_list" // type-1，EIP-2930
+	TxTypeDynamicFee = "dynamic_fee" // type-2，EIP-1559
+	TxTypeBlob       = "blob"        // type-3，EIP-4844
+	TxTypeSetCode    = "set_code"    // type-4，EIP-7702
+)
+
+// token_type 取值；为空时按 contract_address 推断原生币或 ERC20，兼容旧请求
+const (
+	TokenTypeNative  = "native"
+	TokenTypeErc20   = "erc20"
+	TokenTypeErc721  = "erc721"
+	TokenTypeErc1155 = "erc1155"
+)
+
+// txTypeHeader 只解析交易体中的 tx_type，用于分派到具体的交易类型
+type txTypeHeader struct {
+	TxType string `json:"tx_type"`
+}
+
+// TxPayload 描述交易要做什么，各交易类型共用
+type TxPayload struct {
+	FromAddress     string `json:"from_address"`     // 发送方地址
+	ToAddress       string `json:"to_address"`       // 接收方地址
+	Amount          string `json:"amount"`           // 转账金额（wei，建议用字符串避免精度丢失）
+	ContractAddress string `json:"contract_address"` // 合约地址（如果是合约调用）
+	TokenType       string `json:"token_type"`       // native / erc20 / erc721 / erc1155
+	TokenId         string `json:"token_id"`         // NFT tokenId（十进制）；ERC1155 的数量取 amount
+	TokenData       string `json:"token_data"`       // ERC1155 safeTransferFrom 的 data 参数（hex，可选）
+	// 通用合约调用：data 为已编码好的 calldata；或者 method + args 由服务端做 ABI 编码。调用目标为 contract_address，amount 为随调用转入的原生币
+	Data   string          `json:"data,omitempty"`   // 原始 calldata（hex）
+	Method string          `json:"method,omitempty"` // 方法签名，如 "deposit(bytes32,uint256)"
+	Args   json.RawMessage `json:"args,omitempty"`   // JSON 数组形式的参数，按 method 中的类型顺序
+	// 合约部署：to_address 留空，data = bytecode || constructor_args
+	Bytecode        string `json:"bytecode,omitempty"`         // 合约 init bytecode（hex）
+	ConstructorArgs string `json:"constructor_args,omitempty"` // ABI 编码后的构造函数参数（hex，可选）
+	// 网络配置了代币注册表时，未登记的 ERC-20 默认拒绝；确认合约无误后可显式放行
+	AllowUnknownToken bool `json:"allow_unknown_token,omitempty"`
+}
+
 type Eip1559DynamicFeeTx struct {
-	ChainId              string `json:"chain_id"`                 // 链ID (如 Ethereum 主网=1, Goerli=5)
-	Nonce                uint64 `json:"nonce"`                    // 发送者的交易序号
-	FromAddress          string `json:"from_address"`             // 发送方地址
-	ToAddress            string `json:"to_address"`               // 接收方地址
-	GasLimit             uint64 `json:"gas_limit"`                // Gas 上限
-	MaxFeePerGas         string `json:"max_fee_per_gas"`          // 用户愿意付的每单位 Gas 的最高费用（wei）
-	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas"` // 给矿工的小费（tip）
-	Amount               string `json:"amount"`                   // 转账金额（wei，建议用字符串避免精度丢失）
-	ContractAddress      string `json:"contract_address"`         // 合约地址（如果是合约调用）
+	TxType               string        `json:"tx_type"`                  // 交易类型，为空或 dynamic_fee
+	ChainId              string        `json:"chain_id"`                 // 链ID (如 Ethereum 主网=1, Goerli=5)
+	Nonce                uint64        `json:"nonce"`                    // 发送者的交易序号
+	GasLimit             uint64        `json:"gas_limit"`                // Gas 上限
+	MaxFeePerGas         string        `json:"max_fee_per_gas"`          // 用户愿意付的每单位 Gas 的最高费用（wei）
+	MaxPriorityFeePerGas string        `json:"max_priority_fee_per_gas"` // 给矿工的小费（tip）
+	AccessList           []AccessTuple `json:"access_list,omitempty"`    // 可选，预热的地址与存储槽
+	TxPayload
 }
 
 type LegacyFeeTx struct {
-	ChainId         string `json:"chain_id"`
-	Nonce           uint64 `json:"nonce"`
-	FromAddress     string `json:"from_address"`
-	ToAddress       string `json:"to_address"`
-	GasLimit        uint64 `json:"gas_limit"`
-	GasPrice        uint64 `json:"gas_price"`
-	Amount          string `json:"amount"`
-	ContractAddress string `json:"contract_address"`
+	TxType   string `json:"tx_type"` // 固定为 legacy
+	ChainId  string `json:"chain_id"`
+	Nonce    uint64 `json:"nonce"`
+	GasLimit uint64 `json:"gas_limit"`
+	GasPrice uint64 `json:"gas_price"`
+	TxPayload
+}
+
+type Eip2930AccessListTx struct {
+	TxType     string        `json:"tx_type"` // 固定为 access_list
+	ChainId    string        `json:"chain_id"`
+	Nonce      uint64        `json:"nonce"`
+	GasLimit   uint64        `json:"gas_limit"`
+	GasPrice   string        `json:"gas_price"` // wei
+	AccessList []AccessTuple `json:"access_list"`
+	TxPayload
+}
+
+type Eip4844BlobTx struct {
+	TxType               string        `json:"tx_type"` // 固定为 blob
+	ChainId              string        `json:"chain_id"`
+	Nonce                uint64        `json:"nonce"`
+	GasLimit             uint64        `json:"gas_limit"`
+	MaxFeePerGas         string        `json:"max_fee_per_gas"`
+	MaxPriorityFeePerGas string        `json:"max_priority_fee_per_gas"`
+	MaxFeePerBlobGas     string        `json:"max_fee_per_blob_gas"` // blob gas 的最高单价（wei）
+	AccessList           []AccessTuple `json:"access_list,omitempty"`
+	Blobs                []string      `json:"blobs"`           // blob 数据（hex），不足 128KB 的右补零；每个 32 字节域元素必须小于 BLS 模数
+	SidecarVersion       uint8         `json:"sidecar_version"` // 0：每个 blob 一个 proof；1：EIP-7594 cell proofs（Osaka 之后的链）
+	TxPayload
+}
+
+type Eip7702SetCodeTx struct {
+	TxType               string                 `json:"tx_type"` // 固定为 set_code
+	ChainId              string                 `json:"chain_id"`
+	Nonce                uint64                 `json:"nonce"`
+	GasLimit             uint64                 `json:"gas_limit"`
+	MaxFeePerGas         string                 `json:"max_fee_per_gas"`
+	MaxPriorityFeePerGas string                 `json:"max_priority_fee_per_gas"`
+	AccessList           []AccessTuple          `json:"access_list,omitempty"`
+	Authorizations       []Eip7702Authorization `json:"authorizations"`
+	TxPayload
+}
+
+// Eip7702Authorization 是待签名的授权元组，由 public_key 对应的托管私钥签名，把该 EOA 的代码委托给 address
+type Eip7702Authorization struct {
+	ChainId   string `json:"chain_id"`   // 0 表示在任意链上有效
+	Address   string `json:"address"`    // 委托的合约地址，零地址表示清除委托
+	Nonce     uint64 `json:"nonce"`      // 授权账户当前的 nonce；若授权账户同时是交易发送方，需为交易 nonce + 1
+	PublicKey string `json:"public_key"` // 授权账户的公钥
+}
+
+// AccessTuple 是 access list 中的一项：合约地址及其要预热的存储槽（32 字节 hex）
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storage_keys"`
 }
 
 type EthereumSchema struct {
 	RequestId    string              `json:"request_id"`
 	DynamicFeeTx Eip1559DynamicFeeTx `json:"dynamic_fee_tx"`
 	ClassicFeeTx LegacyFeeTx         `json:"classic_fee_tx"`
+	AccessListTx Eip2930AccessListTx `json:"access_list_tx"`
+	BlobTx       Eip4844BlobTx       `json:"blob_tx"`
+	SetCodeTx    Eip7702SetCodeTx    `json:"set_code_tx"`
 }
@@ -0,0 +1,55 @@
+package ethereum
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const eip712DomainType = "EIP712Domain"
+
+// TypedDataHash 是 EIP-712 签名涉及的三个 hash，调用方可据此核对实际签名的内容
+type TypedDataHash struct {
+	DomainSeparator common.Hash
+	StructHash      common.Hash
+	Digest          common.Hash
+}
+
+// ParseTypedData 解析 eth_signTypedData_v4 格式的 TypedData JSON
+func ParseTypedData(typedDataJson string) (*apitypes.TypedData, error) {
+	var typedData apitypes.TypedData
+	if err := json.Unmarshal([]byte(typedDataJson), &typedData); err != nil {
+		return nil, fmt.Errorf("parse typed data fail: %w", err)
+	}
+	if _, ok := typedData.Types[eip712DomainType]; !ok {
+		return nil, fmt.Errorf("typed data types must define %s", eip712DomainType)
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return nil, fmt.Errorf("primary type %q is not defined in types", typedData.PrimaryType)
+	}
+	return &typedData, nil
+}
+
+// HashTypedData 计算 domain separator、struct hash 以及最终的 digest = keccak256(0x19 0x01 || domainSeparator || structHash)
+func HashTypedData(typedData *apitypes.TypedData) (*TypedDataHash, error) {
+	domainSeparator, err := typedData.HashStruct(eip712DomainType, typedData.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("hash domain fail: %w", err)
+	}
+	structHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, fmt.Errorf("hash %s fail: %w", typedData.PrimaryType, err)
+	}
+	rawData := make([]byte, 0, 2+2*common.HashLength)
+	rawData = append(rawData, 0x19, 0x01)
+	rawData = append(rawData, domainSeparator...)
+	rawData = append(rawData, structHash...)
+	return &TypedDataHash{
+		DomainSeparator: common.BytesToHash(domainSeparator),
+		StructHash:      common.BytesToHash(structHash),
+		Digest:          crypto.Keccak256Hash(rawData),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package ethereum
+
+import (
+	"context"
+	"testing"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-712 规范中的 Mail 示例
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func TestHashTypedData(t *testing.T) {
+	typedData, err := ParseTypedData(mailTypedData)
+	if err != nil {
+		t.Fatalf("parse typed data: %v", err)
+	}
+	typedDataHash, err := HashTypedData(typedData)
+	if err != nil {
+		t.Fatalf("hash typed data: %v", err)
+	}
+	if got := typedDataHash.DomainSeparator.Hex(); got != "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f" {
+		t.Errorf("domain separator: got %s", got)
+	}
+	if got := typedDataHash.StructHash.Hex(); got != "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e" {
+		t.Errorf("struct hash: got %s", got)
+	}
+	if got := typedDataHash.Digest.Hex(); got != "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+		t.Errorf("digest: got %s", got)
+	}
+}
+
+func TestSignTypedData(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	resp, err := c.SignTypedData(context.Background(), &wallet.SignTypedDataRequest{
+		PublicKey: pubKey,
+		TypedData: mailTypedData,
+	})
+	if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+		t.Fatalf("sign typed data fail: resp=%v err=%v", resp, err)
+	}
+	sig := hexutil.MustDecode(resp.Signature)
+	if v := sig[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+		t.Fatalf("v: got %d, want 27 or 28", v)
+	}
+	sig[crypto.RecoveryIDOffset] -= 27
+	recovered, err := crypto.Ecrecover(hexutil.MustDecode(resp.Digest), sig)
+	if err != nil || hexutil.Encode(recovered)[2:] != pubKey {
+		t.Errorf("signature does not recover to the signing key: %v", err)
+	}
+
+	resp, _ = c.SignTypedData(context.Background(), &wallet.SignTypedDataRequest{
+		PublicKey: pubKey,
+		TypedData: `{"types": {"Mail": []}, "primaryType": "Mail"}`,
+	})
+	if resp.Code != wallet.ReturnCode_ERROR {
+		t.Errorf("typed data without EIP712Domain must be rejected")
+	}
+}
@@ -0,0 +1,225 @@
+package ethereum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// entry_point_version 取值
+const (
+	EntryPointV06 = "v0.6"
+	EntryPointV07 = "v0.7"
+)
+
+// UserOperation signature_type 取值
+const (
+	UserOpSignatureEthSign = "eth_sign"
+	UserOpSignatureRaw     = "raw"
+)
+
+// 各版本 EntryPoint 的规范部署地址
+var (
+	EntryPointV06Address = common.HexToAddress("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+	EntryPointV07Address = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+)
+
+// UserOperation 是 ERC-4337 的 UserOperation；v0.7 的 initCode、paymasterAndData 已由 factory / paymaster 字段拼好
+type UserOperation struct {
+	Sender               common.Address
+	Nonce                *big.Int
+	InitCode             []byte
+	CallData             []byte
+	CallGasLimit         *big.Int
+	VerificationGasLimit *big.Int
+	PreVerificationGas   *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	PaymasterAndData     []byte
+}
+
+// AccountGasLimits 是 v0.7 PackedUserOperation 的 verificationGasLimit(16 字节) || callGasLimit(16 字节)
+func (op *UserOperation) AccountGasLimits() common.Hash {
+	return packUint128Pair(op.VerificationGasLimit, op.CallGasLimit)
+}
+
+// GasFees 是 v0.7 PackedUserOperation 的 maxPriorityFeePerGas(16 字节) || maxFeePerGas(16 字节)
+func (op *UserOperation) GasFees() common.Hash {
+	return packUint128Pair(op.MaxPriorityFeePerGas, op.MaxFeePerGas)
+}
+
+// UserOpHash 按 EntryPoint.getUserOpHash 计算 keccak256(abi.encode(keccak256(pack(userOp)), entryPoint, chainId))
+func (op *UserOperation) UserOpHash(version string, entryPoint common.Address, chainID *big.Int) (common.Hash, error) {
+	var packed []byte
+	switch version {
+	case EntryPointV06:
+		packed = abiEncodeWords(
+			addressWord(op.Sender),
+			uintWord(op.Nonce),
+			crypto.Keccak256(op.InitCode),
+			crypto.Keccak256(op.CallData),
+			uintWord(op.CallGasLimit),
+			uintWord(op.VerificationGasLimit),
+			uintWord(op.PreVerificationGas),
+			uintWord(op.MaxFeePerGas),
+			uintWord(op.MaxPriorityFeePerGas),
+			crypto.Keccak256(op.PaymasterAndData),
+		)
+	case EntryPointV07:
+		accountGasLimits, gasFees := op.AccountGasLimits(), op.GasFees()
+		packed = abiEncodeWords(
+			addressWord(op.Sender),
+			uintWord(op.Nonce),
+			crypto.Keccak256(op.InitCode),
+			crypto.Keccak256(op.CallData),
+			accountGasLimits[:],
+			uintWord(op.PreVerificationGas),
+			gasFees[:],
+			crypto.Keccak256(op.PaymasterAndData),
+		)
+	default:
+		return common.Hash{}, fmt.Errorf("unsupported entry point version: %s", version)
+	}
+	return crypto.Keccak256Hash(crypto.Keccak256(packed), addressWord(entryPoint), uintWord(chainID)), nil
+}
+
+// UserOpSigningDigest 返回实际被签名的 hash；SimpleAccount 等常见实现都校验 eth_sign 形式
+func UserOpSigningDigest(userOpHash common.Hash, signatureType string) (common.Hash, error) {
+	switch signatureType {
+	case UserOpSignatureEthSign, "":
+		return PersonalMessageHash(userOpHash[:]), nil
+	case UserOpSignatureRaw:
+		return userOpHash, nil
+	default:
+		return common.Hash{}, fmt.Errorf("unsupported user operation signature type: %s", signatureType)
+	}
+}
+
+func defaultEntryPoint(version string) (common.Address, error) {
+	switch version {
+	case EntryPointV06:
+		return EntryPointV06Address, nil
+	case EntryPointV07:
+		return EntryPointV07Address, nil
+	default:
+		return common.Address{}, fmt.Errorf("unsupported entry point version: %s", version)
+	}
+}
+
+// parseUserOperation 解析请求中的 UserOperation；v0.7 未传 init_code / paymaster_and_data 时由拆开的字段拼出
+func parseUserOperation(version string, req *wallet.UserOperation) (*UserOperation, error) {
+	if req == nil {
+		return nil, errors.New("user_op is required")
+	}
+	if !common.IsHexAddress(req.Sender) {
+		return nil, fmt.Errorf("invalid sender: %s", req.Sender)
+	}
+	op := &UserOperation{Sender: common.HexToAddress(req.Sender)}
+	gasBits := 256
+	if version == EntryPointV07 {
+		gasBits = 128
+	}
+	var err error
+	uints := []struct {
+		name  string
+		value string
+		bits  int
+		dst   **big.Int
+	}{
+		{"nonce", req.Nonce, 256, &op.Nonce},
+		{"call_gas_limit", req.CallGasLimit, gasBits, &op.CallGasLimit},
+		{"verification_gas_limit", req.VerificationGasLimit, gasBits, &op.VerificationGasLimit},
+		{"pre_verification_gas", req.PreVerificationGas, 256, &op.PreVerificationGas},
+		{"max_fee_per_gas", req.MaxFeePerGas, gasBits, &op.MaxFeePerGas},
+		{"max_priority_fee_per_gas", req.MaxPriorityFeePerGas, gasBits, &op.MaxPriorityFeePerGas},
+	}
+	for _, field := range uints {
+		if *field.dst, err = parseBoundedUint(field.name, field.value, field.bits); err != nil {
+			return nil, err
+		}
+	}
+	if op.CallData, err = parseOptionalHex("call_data", req.CallData); err != nil {
+		return nil, err
+	}
+	if op.InitCode, err = parseOptionalHex("init_code", req.InitCode); err != nil {
+		return nil, err
+	}
+	if op.PaymasterAndData, err = parseOptionalHex("paymaster_and_data", req.PaymasterAndData); err != nil {
+		return nil, err
+	}
+	if version != EntryPointV07 {
+		return op, nil
+	}
+
+	if req.Factory != "" && len(op.InitCode) == 0 {
+		if !common.IsHexAddress(req.Factory) {
+			return nil, fmt.Errorf("invalid factory: %s", req.Factory)
+		}
+		factoryData, err := parseOptionalHex("factory_data", req.FactoryData)
+		if err != nil {
+			return nil, err
+		}
+		op.InitCode = append(common.HexToAddress(req.Factory).Bytes(), factoryData...)
+	}
+	if req.Paymaster != "" && len(op.PaymasterAndData) == 0 {
+		if !common.IsHexAddress(req.Paymaster) {
+			return nil, fmt.Errorf("invalid paymaster: %s", req.Paymaster)
+		}
+		verificationGasLimit, err := parseBoundedUint("paymaster_verification_gas_limit", req.PaymasterVerificationGasLimit, 128)
+		if err != nil {
+			return nil, err
+		}
+		postOpGasLimit, err := parseBoundedUint("paymaster_post_op_gas_limit", req.PaymasterPostOpGasLimit, 128)
+		if err != nil {
+			return nil, err
+		}
+		paymasterData, err := parseOptionalHex("paymaster_data", req.PaymasterData)
+		if err != nil {
+			return nil, err
+		}
+		paymasterAndData := common.HexToAddress(req.Paymaster).Bytes()
+		paymasterAndData = append(paymasterAndData, common.LeftPadBytes(verificationGasLimit.Bytes(), 16)...)
+		paymasterAndData = append(paymasterAndData, common.LeftPadBytes(postOpGasLimit.Bytes(), 16)...)
+		op.PaymasterAndData = append(paymasterAndData, paymasterData...)
+	}
+	return op, nil
+}
+
+func parseOptionalHex(name string, value string) ([]byte, error) {
+	if value == "" || value == "0x" {
+		return nil, nil
+	}
+	b, err := hexutil.Decode(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return b, nil
+}
+
+func packUint128Pair(high *big.Int, low *big.Int) common.Hash {
+	var packed common.Hash
+	high.FillBytes(packed[:16])
+	low.FillBytes(packed[16:])
+	return packed
+}
+
+func abiEncodeWords(words ...[]byte) []byte {
+	encoded := make([]byte, 0, len(words)*32)
+	for _, word := range words {
+		encoded = append(encoded, word...)
+	}
+	return encoded
+}
+
+func addressWord(address common.Address) []byte {
+	return common.LeftPadBytes(address.Bytes(), 32)
+}
+
+func uintWord(n *big.Int) []byte {
+	return common.LeftPadBytes(n.Bytes(), 32)
+}
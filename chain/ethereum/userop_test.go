@@ -0,0 +1,128 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	wallet "github.com/Brant-Liang/wallet-sign/gen/go"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testUserOperation() *wallet.UserOperation {
+	return &wallet.UserOperation{
+		Sender:               "0x9406Cc6185a346906296840746125a0E44976454",
+		Nonce:                "0x1",
+		CallData:             "0xb61d27f6",
+		CallGasLimit:         "100000",
+		VerificationGasLimit: "150000",
+		PreVerificationGas:   "50000",
+		MaxFeePerGas:         "30000000000",
+		MaxPriorityFeePerGas: "1000000000",
+	}
+}
+
+// abiPackedHash 用 go-ethereum 的 abi 编码器按 EntryPoint 源码重新计算 userOpHash
+func abiPackedHash(t *testing.T, signature string, values []interface{}, entryPoint common.Address, chainID *big.Int) common.Hash {
+	t.Helper()
+	_, arguments, err := ParseMethodSignature("pack(" + signature + ")")
+	if err != nil {
+		t.Fatalf("parse signature: %v", err)
+	}
+	packed, err := arguments.Pack(values...)
+	if err != nil {
+		t.Fatalf("pack user op: %v", err)
+	}
+	_, outer, _ := ParseMethodSignature("hash(bytes32,address,uint256)")
+	encoded, err := outer.Pack(crypto.Keccak256Hash(packed), entryPoint, chainID)
+	if err != nil {
+		t.Fatalf("pack user op hash: %v", err)
+	}
+	return crypto.Keccak256Hash(encoded)
+}
+
+func TestUserOpHash(t *testing.T) {
+	op, err := parseUserOperation(EntryPointV06, testUserOperation())
+	if err != nil {
+		t.Fatalf("parse user op: %v", err)
+	}
+	chainID := big.NewInt(1)
+	emptyHash := crypto.Keccak256Hash(nil)
+
+	want := abiPackedHash(t, "address,uint256,bytes32,bytes32,uint256,uint256,uint256,uint256,uint256,bytes32", []interface{}{
+		op.Sender, op.Nonce, emptyHash, crypto.Keccak256Hash(op.CallData),
+		op.CallGasLimit, op.VerificationGasLimit, op.PreVerificationGas, op.MaxFeePerGas, op.MaxPriorityFeePerGas, emptyHash,
+	}, EntryPointV06Address, chainID)
+	if got, _ := op.UserOpHash(EntryPointV06, EntryPointV06Address, chainID); got != want {
+		t.Errorf("v0.6 user op hash: got %s, want %s", got, want)
+	}
+
+	want = abiPackedHash(t, "address,uint256,bytes32,bytes32,bytes32,uint256,bytes32,bytes32", []interface{}{
+		op.Sender, op.Nonce, emptyHash, crypto.Keccak256Hash(op.CallData),
+		op.AccountGasLimits(), op.PreVerificationGas, op.GasFees(), emptyHash,
+	}, EntryPointV07Address, chainID)
+	if got, _ := op.UserOpHash(EntryPointV07, EntryPointV07Address, chainID); got != want {
+		t.Errorf("v0.7 user op hash: got %s, want %s", got, want)
+	}
+	if got := op.AccountGasLimits().Hex(); got != "0x000000000000000000000000000249f0000000000000000000000000000186a0" {
+		t.Errorf("account gas limits: got %s", got)
+	}
+}
+
+func TestSignUserOperation(t *testing.T) {
+	c, pubKey := newTestAdaptor(t)
+	owner, _ := publicKeyToAddress(pubKey)
+	userOp := testUserOperation()
+	userOp.Factory = "0x9406Cc6185a346906296840746125a0E44976454"
+	userOp.FactoryData = "0x5fbfb9cf"
+	userOp.Paymaster = "0x0000000000000039cd5e8aE05257CE51C473ddd1"
+	userOp.PaymasterVerificationGasLimit = "60000"
+	userOp.PaymasterPostOpGasLimit = "0"
+
+	for _, signatureType := range []string{UserOpSignatureEthSign, UserOpSignatureRaw} {
+		resp, err := c.SignUserOperation(context.Background(), &wallet.SignUserOperationRequest{
+			PublicKey:         pubKey,
+			EntryPointVersion: EntryPointV07,
+			ChainId:           "1",
+			UserOp:            userOp,
+			SignatureType:     signatureType,
+		})
+		if err != nil || resp.Code != wallet.ReturnCode_SUCCESS {
+			t.Fatalf("%s: sign user op fail: resp=%v err=%v", signatureType, resp, err)
+		}
+		if resp.UserOp.Signature != resp.Signature || userOp.Signature != "" {
+			t.Errorf("%s: signature must be populated on a copy of the user op", signatureType)
+		}
+		if want := userOp.Factory + userOp.FactoryData[2:]; resp.UserOp.InitCode != hexutil.Encode(hexutil.MustDecode(want)) {
+			t.Errorf("%s: init code: got %s", signatureType, resp.UserOp.InitCode)
+		}
+		if n := len(hexutil.MustDecode(resp.UserOp.PaymasterAndData)); n != 20+16+16 {
+			t.Errorf("%s: paymaster and data length: got %d", signatureType, n)
+		}
+
+		digest, _ := UserOpSigningDigest(common.HexToHash(resp.UserOpHash), signatureType)
+		sig := hexutil.MustDecode(resp.Signature)
+		sig[crypto.RecoveryIDOffset] -= 27
+		recovered, err := crypto.SigToPub(digest[:], sig)
+		if err != nil || crypto.PubkeyToAddress(*recovered) != owner {
+			t.Fatalf("%s: recovered signer mismatch: err=%v", signatureType, err)
+		}
+	}
+
+	resp, _ := c.SignUserOperation(context.Background(), &wallet.SignUserOperationRequest{
+		PublicKey:         pubKey,
+		EntryPointVersion: "v0.8",
+		ChainId:           "1",
+		UserOp:            testUserOperation(),
+	})
+	if resp.Code != wallet.ReturnCode_ERROR {
+		t.Errorf("unknown entry point version must be rejected")
+	}
+	overflow := testUserOperation()
+	overflow.CallGasLimit = "0x100000000000000000000000000000000" // 2^128
+	if _, err := parseUserOperation(EntryPointV07, overflow); err == nil {
+		t.Errorf("v0.7 gas limit above uint128 must be rejected")
+	}
+}
@@ -116,3 +116,59 @@ func (c ChainAdaptor) BuildAndSignBatchTransaction(ctx context.Context, req *wal
 	//TODO implement me
 	panic("implement me")
 }
+
+func (c ChainAdaptor) SignTypedData(ctx context.Context, req *wallet.SignTypedDataRequest) (*wallet.SignTypedDataResponse, error) {
+	return &wallet.SignTypedDataResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
+
+func (c ChainAdaptor) SignPersonalMessage(ctx context.Context, req *wallet.SignPersonalMessageRequest) (*wallet.SignPersonalMessageResponse, error) {
+	return &wallet.SignPersonalMessageResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
+
+func (c ChainAdaptor) SignPermit(ctx context.Context, req *wallet.SignPermitRequest) (*wallet.SignPermitResponse, error) {
+	return &wallet.SignPermitResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
+
+func (c ChainAdaptor) DecodeTransaction(ctx context.Context, req *wallet.DecodeTransactionRequest) (*wallet.DecodeTransactionResponse, error) {
+	return &wallet.DecodeTransactionResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
+
+func (c ChainAdaptor) SignSafeTransaction(ctx context.Context, req *wallet.SignSafeTransactionRequest) (*wallet.SignSafeTransactionResponse, error) {
+	return &wallet.SignSafeTransactionResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
+
+func (c ChainAdaptor) SignUserOperation(ctx context.Context, req *wallet.SignUserOperationRequest) (*wallet.SignUserOperationResponse, error) {
+	return &wallet.SignUserOperationResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
+
+func (c ChainAdaptor) SignSiweMessage(ctx context.Context, req *wallet.SignSiweMessageRequest) (*wallet.SignSiweMessageResponse, error) {
+	return &wallet.SignSiweMessageResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
+
+func (c ChainAdaptor) SignFlashbotsBundle(ctx context.Context, req *wallet.SignFlashbotsBundleRequest) (*wallet.SignFlashbotsBundleResponse, error) {
+	return &wallet.SignFlashbotsBundleResponse{
+		Code:    wallet.ReturnCode_ERROR,
+		Message: config.UnsupportedOperation,
+	}, nil
+}
@@ -65,7 +65,14 @@ func NewChainDispatcher(conf *config.Config, db *leveldb.Keys) (*ChainDispatcher
 		if factory, ok := chainAdaptorFactoryMap[chainName]; ok {
 			adaptor, err := factory(conf, db, hsmClient)
 			if err != nil {
-				log.Error("chain adapter factory err:", err, "chain:", chainName)
+				return nil, fmt.Errorf("new chain adapter %s fail: %w", chainName, err)
+			}
+			dispatcher.registry[chainName] = adaptor
+		} else if network := conf.EvmNetwork(chainName); network != nil {
+			// evm_networks 中配置的网络复用 Ethereum 适配器，按各自的 chain_id 校验请求
+			adaptor, err := ethereum.NewEvmChainAdapter(conf, network, db, hsmClient)
+			if err != nil {
+				return nil, fmt.Errorf("new evm chain adapter %s fail: %w", chainName, err)
 			}
 			dispatcher.registry[chainName] = adaptor
 		} else {
@@ -212,3 +219,91 @@ func (d *ChainDispatcher) BuildAndSignBatchTransaction(ctx context.Context, requ
 	}
 	return d.registry[request.ChainName].BuildAndSignBatchTransaction(ctx, request)
 }
+
+func (d *ChainDispatcher) SignTypedData(ctx context.Context, request *wallet.SignTypedDataRequest) (*wallet.SignTypedDataResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.SignTypedDataResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].SignTypedData(ctx, request)
+}
+
+func (d *ChainDispatcher) SignPersonalMessage(ctx context.Context, request *wallet.SignPersonalMessageRequest) (*wallet.SignPersonalMessageResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.SignPersonalMessageResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].SignPersonalMessage(ctx, request)
+}
+
+func (d *ChainDispatcher) SignPermit(ctx context.Context, request *wallet.SignPermitRequest) (*wallet.SignPermitResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.SignPermitResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].SignPermit(ctx, request)
+}
+
+func (d *ChainDispatcher) DecodeTransaction(ctx context.Context, request *wallet.DecodeTransactionRequest) (*wallet.DecodeTransactionResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.DecodeTransactionResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].DecodeTransaction(ctx, request)
+}
+
+func (d *ChainDispatcher) SignSafeTransaction(ctx context.Context, request *wallet.SignSafeTransactionRequest) (*wallet.SignSafeTransactionResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.SignSafeTransactionResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].SignSafeTransaction(ctx, request)
+}
+
+func (d *ChainDispatcher) SignUserOperation(ctx context.Context, request *wallet.SignUserOperationRequest) (*wallet.SignUserOperationResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.SignUserOperationResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].SignUserOperation(ctx, request)
+}
+
+func (d *ChainDispatcher) SignSiweMessage(ctx context.Context, request *wallet.SignSiweMessageRequest) (*wallet.SignSiweMessageResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.SignSiweMessageResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].SignSiweMessage(ctx, request)
+}
+
+func (d *ChainDispatcher) SignFlashbotsBundle(ctx context.Context, request *wallet.SignFlashbotsBundleRequest) (*wallet.SignFlashbotsBundleResponse, error) {
+	resp := d.preHandler(request)
+	if resp != nil {
+		return &wallet.SignFlashbotsBundleResponse{
+			Code:    resp.Code,
+			Message: resp.Message,
+		}, nil
+	}
+	return d.registry[request.ChainName].SignFlashbotsBundle(ctx, request)
+}
@@ -12,13 +12,56 @@ type ServerConfig struct {
 }
 
 type Config struct {
-	LevelDbPath     string       `yaml:"level_db_path"`
-	RpcServer       ServerConfig `yaml:"rpc_server"`
-	CredentialsFile string       `yaml:"credentials_file"`
-	KeyPath         string       `yaml:"key_path"`
-	KeyName         string       `yaml:"key_name"`
-	HsmEnable       bool         `yaml:"hsm_enable"`
-	Chains          []string     `yaml:"chains"`
+	LevelDbPath                string       `yaml:"level_db_path"`
+	RpcServer                  ServerConfig `yaml:"rpc_server"`
+	CredentialsFile            string       `yaml:"credentials_file"`
+	KeyPath                    string       `yaml:"key_path"`
+	KeyName                    string       `yaml:"key_name"`
+	HsmEnable                  bool         `yaml:"hsm_enable"`
+	Chains                     []string     `yaml:"chains"`
+	BatchSignConcurrency       int          `yaml:"batch_sign_concurrency"`
+	AuthorizationDelegates     []string     `yaml:"authorization_delegates"`       // EIP-7702 授权允许委托的合约地址，为空时只允许清除委托（零地址）
+	AllowAnyChainAuthorization bool         `yaml:"allow_any_chain_authorization"` // 允许 chain_id 为 0、在所有 EVM 链上有效的 EIP-7702 授权，默认拒绝
+	PermitPolicy               PermitPolicy `yaml:"permit_policy"`
+	EvmNetworks                []EvmNetwork `yaml:"evm_networks"`
+	FlashbotsSearcherKey       string       `yaml:"flashbots_searcher_key"` // 签 X-Flashbots-Signature 的 searcher 公钥，该密钥只用于 relay 认证，不能签交易或消息
+}
+
+type PermitPolicy struct {
+	MaxDeadlineSeconds uint64   `yaml:"max_deadline_seconds"`
+	AllowedSpenders    []string `yaml:"allowed_spenders"`
+}
+
+// EvmNetwork 描述一条复用 Ethereum 适配器的 EVM 网络，name 即请求中的 chain_name
+type EvmNetwork struct {
+	Name                 string     `yaml:"name"`
+	ChainId              uint64     `yaml:"chain_id"`
+	Symbol               string     `yaml:"symbol"`
+	MaxFeePerGas         string     `yaml:"max_fee_per_gas"`          // wei，legacy 交易比较 gas_price，为空不限制
+	MaxPriorityFeePerGas string     `yaml:"max_priority_fee_per_gas"` // wei，为空不限制
+	MaxFeePerBlobGas     string     `yaml:"max_fee_per_blob_gas"`     // wei，为空不限制
+	MaxGasLimit          uint64     `yaml:"max_gas_limit"`            // 为 0 不限制
+	MaxTotalFee          string     `yaml:"max_total_fee"`            // wei，gas_limit * max_fee（含 blob gas）的上限，为空不限制
+	TxTypes              []string   `yaml:"tx_types"`                 // 允许的交易类型，为空表示全部允许
+	Tokens               []EvmToken `yaml:"tokens"`                   // ERC-20 代币注册表，配置后未登记的代币默认拒绝
+	SimulationFork       string     `yaml:"simulation_fork"`          // 预执行按该升级（london/shanghai/cancun/prague）的规则执行；为空时只有以太坊主网与测试网支持预执行
+}
+
+// EvmToken 是代币注册表中的一项，symbol 在同一网络内不区分大小写唯一
+type EvmToken struct {
+	Address  string `yaml:"address"`
+	Symbol   string `yaml:"symbol"`
+	Decimals uint8  `yaml:"decimals"`
+}
+
+// EvmNetwork 按名称查找 EVM 网络配置，未配置时返回 nil
+func (c *Config) EvmNetwork(name string) *EvmNetwork {
+	for i := range c.EvmNetworks {
+		if c.EvmNetworks[i].Name == name {
+			return &c.EvmNetworks[i]
+		}
+	}
+	return nil
 }
 
 func NewConfig(path string) (*Config, error) {
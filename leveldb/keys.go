@@ -1,6 +1,22 @@
 package leveldb
 
-import "github.com/ethereum/go-ethereum/log"
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// 公钥 -> 私钥 之外的记录（地址索引、nonce 记录等）都放在以 0x00 开头的命名空间下：
+// 公钥 key 是 hex 字符串，遍历密钥时跳过该命名空间即可，两类记录不会混在一起
+const (
+	metaNamespace          = "\x00"
+	addressKeyPrefix       = metaNamespace + "addr:"
+	addressIndexVersionKey = metaNamespace + "meta:address-index"
+)
 
 type Keys struct {
 	db *LevelStore
@@ -12,9 +28,14 @@ func NewKeyStore(path string) (*Keys, error) {
 		log.Error("Could not create leveldb database.")
 		return nil, err
 	}
-	return &Keys{
+	keys := &Keys{
 		db: db,
-	}, nil
+	}
+	if err := keys.backfillAddressIndex(); err != nil {
+		log.Error("backfill address index fail", "err", err)
+		return nil, err
+	}
+	return keys, nil
 }
 
 func (k *Keys) GetPrivKey(publicKey string) (string, bool) {
@@ -36,6 +57,89 @@ func (k *Keys) StoreKeys(keyList []Key) bool {
 			log.Error("store key value fail", "err", err, "key", key, "value", value)
 			return false
 		}
+		if item.Address != "" {
+			if err := k.db.Put(addressKey(item.Address), []byte(item.Pubkey)); err != nil {
+				log.Error("store address index fail", "err", err, "address", item.Address)
+				return false
+			}
+		}
 	}
 	return true
 }
+
+// GetPubKeyByAddress 通过地址索引查找公钥，地址不区分大小写
+func (k *Keys) GetPubKeyByAddress(address string) (string, bool) {
+	data, err := k.db.Get(addressKey(address))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+func addressKey(address string) []byte {
+	return []byte(addressKeyPrefix + strings.ToLower(address))
+}
+
+// backfillAddressIndex 为建立地址索引之前存入的 secp256k1 公钥补写 地址 -> 公钥 索引，只在首次启动时执行一次；
+// ed25519 等无法推导以太坊地址的公钥跳过，已有的索引项不覆盖。
+// 旧数据无法区分密钥是哪条链创建的，Bitcoin 的 secp256k1 公钥也会被索引：Ethereum 适配器本来就接受任意 secp256k1 公钥签名，
+// 按地址查找并不放宽可签名的密钥范围
+func (k *Keys) backfillAddressIndex() error {
+	if _, err := k.db.Get([]byte(addressIndexVersionKey)); err == nil {
+		return nil
+	} else if !errors.Is(err, leveldb.ErrNotFound) {
+		return err
+	}
+	batch := new(leveldb.Batch)
+	indexed := 0
+	iter := k.db.NewIterator(nil, nil)
+	for iter.Next() {
+		key := string(iter.Key())
+		if strings.HasPrefix(key, metaNamespace) {
+			continue
+		}
+		address, ok := publicKeyAddress(key)
+		if !ok {
+			continue
+		}
+		if _, err := k.db.Get(addressKey(address)); err == nil {
+			continue
+		}
+		batch.Put(addressKey(address), []byte(key))
+		indexed++
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	batch.Put([]byte(addressIndexVersionKey), []byte("1"))
+	if err := k.db.Write(batch, nil); err != nil {
+		return err
+	}
+	log.Info("backfill address index", "indexed", indexed)
+	return nil
+}
+
+// publicKeyAddress 从 hex 编码的 secp256k1 公钥（压缩或非压缩）推导以太坊地址
+func publicKeyAddress(publicKey string) (string, bool) {
+	b, err := hex.DecodeString(strings.TrimPrefix(publicKey, "0x"))
+	if err != nil {
+		return "", false
+	}
+	switch len(b) {
+	case 65:
+		pub, err := crypto.UnmarshalPubkey(b)
+		if err != nil {
+			return "", false
+		}
+		return crypto.PubkeyToAddress(*pub).Hex(), true
+	case 33:
+		pub, err := crypto.DecompressPubkey(b)
+		if err != nil {
+			return "", false
+		}
+		return crypto.PubkeyToAddress(*pub).Hex(), true
+	default:
+		return "", false
+	}
+}
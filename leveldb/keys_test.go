@@ -0,0 +1,62 @@
+package leveldb
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestBackfillAddressIndex(t *testing.T) {
+	path := t.TempDir()
+	privateKey, _ := crypto.GenerateKey()
+	pubKey := hex.EncodeToString(crypto.FromECDSAPub(&privateKey.PublicKey))
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+
+	// 模拟建立地址索引之前的数据：只有 公钥 -> 私钥
+	db, err := NewLevelStore(path)
+	if err != nil {
+		t.Fatalf("open leveldb: %v", err)
+	}
+	db.Put([]byte(pubKey), crypto.FromECDSA(privateKey))
+	db.Put([]byte("ed25519pubkey"), []byte{0x01})
+	db.Close()
+
+	keys, err := NewKeyStore(path)
+	if err != nil {
+		t.Fatalf("open key store: %v", err)
+	}
+	if indexed, ok := keys.GetPubKeyByAddress(address); !ok || indexed != pubKey {
+		t.Errorf("existing key must be indexed by address: got %q, %v", indexed, ok)
+	}
+	if priv, ok := keys.GetPrivKey(pubKey); !ok || priv != hex.EncodeToString(crypto.FromECDSA(privateKey)) {
+		t.Errorf("private key must be untouched")
+	}
+
+	// 回填只执行一次；之后只有带 address 存入的密钥才建立索引
+	other, _ := crypto.GenerateKey()
+	otherPubKey := hex.EncodeToString(crypto.CompressPubkey(&other.PublicKey))
+	if !keys.StoreKeys([]Key{{PrivateKey: hex.EncodeToString(crypto.FromECDSA(other)), Pubkey: otherPubKey}}) {
+		t.Fatal("store keys fail")
+	}
+	if _, ok := keys.GetPubKeyByAddress(crypto.PubkeyToAddress(other.PublicKey).Hex()); ok {
+		t.Errorf("key stored without address must not be indexed")
+	}
+	evm, _ := crypto.GenerateKey()
+	evmPubKey := hex.EncodeToString(crypto.FromECDSAPub(&evm.PublicKey))
+	evmAddress := crypto.PubkeyToAddress(evm.PublicKey).Hex()
+	if !keys.StoreKeys([]Key{{PrivateKey: hex.EncodeToString(crypto.FromECDSA(evm)), Pubkey: evmPubKey, Address: evmAddress}}) {
+		t.Fatal("store keys fail")
+	}
+	if indexed, ok := keys.GetPubKeyByAddress(evmAddress); !ok || indexed != evmPubKey {
+		t.Errorf("key stored with address must be indexed: got %q, %v", indexed, ok)
+	}
+	keys.db.Close()
+	if keys, err = NewKeyStore(path); err != nil {
+		t.Fatalf("reopen key store: %v", err)
+	}
+	if _, ok := keys.GetPubKeyByAddress(address); !ok {
+		t.Errorf("index must survive reopening")
+	}
+	keys.db.Close()
+}
@@ -0,0 +1,117 @@
+package leveldb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// 已签名 nonce 的 key：<metaNamespace>nonce:<chainId>:<address>:<nonce> 记录该 nonce 上签过的交易，
+// <metaNamespace>nonce-high:<chainId>:<address> 记录该地址签过的最高 nonce 及其 digest
+const (
+	signedNonceKeyPrefix  = metaNamespace + "nonce:"
+	highestNonceKeyPrefix = metaNamespace + "nonce-high:"
+)
+
+// SignedNonce 是某地址在某个 nonce 上最近一次签名的交易
+type SignedNonce struct {
+	Digest        string `json:"digest"`
+	TxHash        string `json:"tx_hash"`
+	GasFeeCap     string `json:"gas_fee_cap"`                // legacy / 2930 交易为 gas_price
+	GasTipCap     string `json:"gas_tip_cap"`                // legacy / 2930 交易为 gas_price
+	BlobGasFeeCap string `json:"blob_gas_fee_cap,omitempty"` // 仅 blob 交易
+	Replacements  int    `json:"replacements"`               // 该 nonce 被显式替换的次数
+	SignedAt      int64  `json:"signed_at"`
+}
+
+// GetSignedNonce 读取地址在 nonce 上的签名记录，地址不区分大小写
+func (k *Keys) GetSignedNonce(chainID string, address string, nonce uint64) (*SignedNonce, bool, error) {
+	data, err := k.db.Get(signedNonceKey(chainID, address, nonce))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var record SignedNonce
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, false, fmt.Errorf("decode signed nonce record: %w", err)
+	}
+	return &record, true, nil
+}
+
+// HighestNonce 是某地址签过的最高 nonce 及该 nonce 上最近一次签名的交易
+type HighestNonce struct {
+	Nonce  uint64 `json:"nonce"`
+	Digest string `json:"digest"`
+	TxHash string `json:"tx_hash"`
+}
+
+// GetHighestNonce 读取地址签过的最高 nonce，地址不区分大小写
+func (k *Keys) GetHighestNonce(chainID string, address string) (*HighestNonce, bool, error) {
+	data, err := k.db.Get(highestNonceKey(chainID, address))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var highest HighestNonce
+	if err := json.Unmarshal(data, &highest); err != nil {
+		return nil, false, fmt.Errorf("decode highest nonce record: %w", err)
+	}
+	return &highest, true, nil
+}
+
+// SignedNonceEntry 是一条待写入的 nonce 签名记录
+type SignedNonceEntry struct {
+	ChainID string
+	Address string
+	Nonce   uint64
+	Record  *SignedNonce
+}
+
+// StoreSignedNonces 在同一个 batch 中写入多条 nonce 记录，并同步更新各地址的最高 nonce（同一 nonce 被替换时更新 digest），
+// 要么全部写入要么全部不写；调用方需持有相关地址的 nonce 锁
+func (k *Keys) StoreSignedNonces(entries []SignedNonceEntry) error {
+	batch := new(leveldb.Batch)
+	highest := make(map[string]*HighestNonce)
+	for _, entry := range entries {
+		data, err := json.Marshal(entry.Record)
+		if err != nil {
+			return err
+		}
+		batch.Put(signedNonceKey(entry.ChainID, entry.Address, entry.Nonce), data)
+
+		key := string(highestNonceKey(entry.ChainID, entry.Address))
+		current, ok := highest[key]
+		if !ok {
+			if current, _, err = k.GetHighestNonce(entry.ChainID, entry.Address); err != nil {
+				return err
+			}
+		}
+		if current == nil || entry.Nonce >= current.Nonce {
+			current = &HighestNonce{Nonce: entry.Nonce, Digest: entry.Record.Digest, TxHash: entry.Record.TxHash}
+		}
+		highest[key] = current
+	}
+	for key, record := range highest {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		batch.Put([]byte(key), data)
+	}
+	return k.db.Write(batch, nil)
+}
+
+func signedNonceKey(chainID string, address string, nonce uint64) []byte {
+	return []byte(fmt.Sprintf("%s%s:%s:%d", signedNonceKeyPrefix, chainID, strings.ToLower(address), nonce))
+}
+
+func highestNonceKey(chainID string, address string) []byte {
+	return []byte(fmt.Sprintf("%s%s:%s", highestNonceKeyPrefix, chainID, strings.ToLower(address)))
+}
@@ -0,0 +1,39 @@
+package leveldb
+
+import (
+	"testing"
+)
+
+func TestStoreSignedNoncesTracksHighest(t *testing.T) {
+	keys, err := NewKeyStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("open key store: %v", err)
+	}
+	defer keys.db.Close()
+	address := "0xAbC0000000000000000000000000000000000001"
+	store := func(entries ...SignedNonceEntry) {
+		if err := keys.StoreSignedNonces(entries); err != nil {
+			t.Fatalf("store signed nonces: %v", err)
+		}
+	}
+	entry := func(nonce uint64, digest string) SignedNonceEntry {
+		return SignedNonceEntry{ChainID: "1", Address: address, Nonce: nonce, Record: &SignedNonce{Digest: digest, TxHash: "tx-" + digest}}
+	}
+
+	if _, ok, err := keys.GetHighestNonce("1", address); ok || err != nil {
+		t.Fatalf("no highest nonce before signing: ok=%v err=%v", ok, err)
+	}
+	// 同一 batch 中取最高的 nonce，与写入顺序无关
+	store(entry(5, "a"), entry(3, "b"))
+	// 更低的 nonce 不覆盖；最高 nonce 被替换时更新 digest
+	store(entry(4, "c"))
+	store(entry(5, "d"))
+
+	highest, ok, err := keys.GetHighestNonce("1", "0xabc0000000000000000000000000000000000001")
+	if err != nil || !ok || highest.Nonce != 5 || highest.Digest != "d" || highest.TxHash != "tx-d" {
+		t.Errorf("highest nonce: got %+v ok=%v err=%v", highest, ok, err)
+	}
+	if _, ok, _ := keys.GetHighestNonce("137", address); ok {
+		t.Errorf("highest nonce must be tracked per chain")
+	}
+}
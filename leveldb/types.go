@@ -3,4 +3,5 @@ package leveldb
 type Key struct {
 	PrivateKey string
 	Pubkey     string
+	Address    string // 可选，非空时同时写入 地址 -> 公钥 的索引；只有 EVM 适配器创建的密钥会带地址
 }